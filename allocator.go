@@ -0,0 +1,271 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// ErrNotAllocated is returned when a value that is not currently allocated is
+// released, e.g. because it has already been released.
+var ErrNotAllocated = errors.New("value is not allocated")
+
+// The number of values tracked by each chunk of an allocator's bitmap.
+const chunkBits = 4096
+
+// A chunk of an allocator's bitmap and the number of bits that are set.
+type allocChunk struct {
+	words [chunkBits / 64]uint64
+	count uint64
+}
+
+// Shared by every chunk whose values are all allocated.
+var fullChunk = new(allocChunk)
+
+// Allocator hands out reusable values from a bounded range, such as port
+// numbers, worker slots or file descriptors. Unlike a Sequence, values are
+// given back with Release and Allocate always returns the lowest value that
+// is not currently allocated. Releasing a value that is not allocated returns
+// ErrNotAllocated so that double releases are detected.
+//
+// Allocated values are tracked with a bitmap that is split into chunks of
+// 4096 values. Chunks whose values are all free take no space and chunks
+// whose values are all allocated share a single bitmap, but every chunk with
+// an allocated value still costs a map entry, so memory grows with the number
+// of allocated values divided by 4096 rather than with the size of the range.
+// Allocate walks those chunks from the lowest free value upwards, so once a
+// value below a long run of full chunks is reallocated, the next Allocate
+// takes time proportional to the length of the run. An Allocator is safe for
+// concurrent use.
+type Allocator struct {
+	mu          sync.Mutex
+	minvalue    uint64                 // The minimum value of the range
+	maxvalue    uint64                 // The maximum value of the range
+	increment   uint64                 // The step between values in the range
+	size        uint64                 // The number of values in the range
+	allocated   uint64                 // The number of values currently allocated
+	lowest      uint64                 // No index below this one is free
+	chunks      map[uint64]*allocChunk // Chunks with allocated values by index
+	initialized bool                   // Flag that indicates if the allocator has been initialized
+}
+
+// NewAllocator constructs an Allocator over the range specified by the
+// params, which are interpreted in the same way as by Sequence.Init, e.g.
+// NewAllocator(1024, 65535) allocates unprivileged port numbers.
+func NewAllocator(params ...uint64) (*Allocator, error) {
+	a := new(Allocator)
+	err := a.Init(params...)
+	return a, err
+}
+
+// Init the range of the allocator as described by Sequence.Init.
+func (a *Allocator) Init(params ...uint64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.initialized {
+		return errors.New("cannot re-initialize an allocator")
+	}
+
+	// Validate the parameters the same way as a Sequence.
+	bounds := new(Sequence)
+	if err := bounds.Init(params...); err != nil {
+		return err
+	}
+
+	a.minvalue, a.maxvalue, a.increment = bounds.minvalue, bounds.maxvalue, bounds.increment
+	a.size = (a.maxvalue-a.minvalue)/a.increment + 1
+	a.chunks = make(map[uint64]*allocChunk)
+	a.initialized = true
+	return nil
+}
+
+// Allocate returns the lowest value in the range that is not allocated. An
+// error wrapping ErrExhausted is returned if every value is allocated.
+func (a *Allocator) Allocate() (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.initialized {
+		return 0, errors.New("allocator has not been initialized")
+	}
+
+	idx, ok := a.free()
+	if !ok {
+		return 0, fmt.Errorf("%w: all %d values are allocated", ErrExhausted, a.size)
+	}
+
+	c := idx / chunkBits
+	chunk, ok := a.chunks[c]
+	if !ok {
+		chunk = new(allocChunk)
+		a.chunks[c] = chunk
+	}
+
+	bit := idx % chunkBits
+	chunk.words[bit/64] |= 1 << (bit % 64)
+	if chunk.count++; chunk.count == a.capacity(c) {
+		a.chunks[c] = fullChunk
+	}
+
+	a.allocated++
+	a.lowest = idx + 1
+	return a.minvalue + idx*a.increment, nil
+}
+
+// Release the value so that it can be allocated again. ErrNotAllocated is
+// returned if the value is in the range but is not currently allocated.
+func (a *Allocator) Release(val uint64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	idx, err := a.index(val)
+	if err != nil {
+		return err
+	}
+
+	c := idx / chunkBits
+	chunk, ok := a.chunks[c]
+	if !ok {
+		return ErrNotAllocated
+	}
+
+	if chunk == fullChunk {
+		// Expand the chunk so that the value can be released from it.
+		chunk = &allocChunk{count: a.capacity(c)}
+		for i := uint64(0); i < chunk.count; i++ {
+			chunk.words[i/64] |= 1 << (i % 64)
+		}
+		a.chunks[c] = chunk
+	}
+
+	bit := idx % chunkBits
+	mask := uint64(1) << (bit % 64)
+	if chunk.words[bit/64]&mask == 0 {
+		return ErrNotAllocated
+	}
+
+	chunk.words[bit/64] &^= mask
+	if chunk.count--; chunk.count == 0 {
+		delete(a.chunks, c)
+	}
+
+	a.allocated--
+	if idx < a.lowest {
+		a.lowest = idx
+	}
+	return nil
+}
+
+// IsAllocated returns true if the value is currently allocated.
+func (a *Allocator) IsAllocated(val uint64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	idx, err := a.index(val)
+	if err != nil {
+		return false
+	}
+
+	chunk, ok := a.chunks[idx/chunkBits]
+	if !ok {
+		return false
+	}
+
+	bit := idx % chunkBits
+	return chunk == fullChunk || chunk.words[bit/64]&(1<<(bit%64)) != 0
+}
+
+// Allocated returns the number of values that are currently allocated.
+func (a *Allocator) Allocated() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.allocated
+}
+
+// Bounds returns the minimum value, maximum value and increment of the range.
+func (a *Allocator) Bounds() (minvalue, maxvalue, increment uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.minvalue, a.maxvalue, a.increment
+}
+
+// Remaining returns the number of values that are free to be allocated.
+func (a *Allocator) Remaining() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.size - a.allocated
+}
+
+// String returns a human readable representation of the allocator.
+func (a *Allocator) String() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d := fmt.Sprintf("incremented by %d between %d and %d", a.increment, a.minvalue, a.maxvalue)
+	if !a.initialized {
+		return fmt.Sprintf("Uninitialized Allocator %s", d)
+	}
+	return fmt.Sprintf("Allocator with %d of %d values allocated %s", a.allocated, a.size, d)
+}
+
+// Returns the index into the range of the value. Must be called with the lock.
+func (a *Allocator) index(val uint64) (uint64, error) {
+	if !a.initialized {
+		return 0, errors.New("allocator has not been initialized")
+	}
+
+	if val < a.minvalue || val > a.maxvalue || (val-a.minvalue)%a.increment != 0 {
+		return 0, fmt.Errorf("value %d is not in the range of the allocator", val)
+	}
+	return (val - a.minvalue) / a.increment, nil
+}
+
+// Returns the number of values tracked by the chunk; only the last chunk can
+// track fewer than chunkBits values.
+func (a *Allocator) capacity(c uint64) uint64 {
+	if rem := a.size - c*chunkBits; rem < chunkBits {
+		return rem
+	}
+	return chunkBits
+}
+
+// Returns the lowest free index, searching from the lowest index that might
+// be free. Must be called with the lock.
+func (a *Allocator) free() (uint64, bool) {
+	last := (a.size - 1) / chunkBits
+	for idx := a.lowest; idx < a.size; {
+		c := idx / chunkBits
+		chunk, ok := a.chunks[c]
+		if !ok {
+			return idx, true
+		}
+
+		if chunk != fullChunk {
+			for w := (idx % chunkBits) / 64; w < uint64(len(chunk.words)); w++ {
+				free := ^chunk.words[w]
+				if w == (idx%chunkBits)/64 {
+					// Ignore the bits below the search index.
+					free &= ^uint64(0) << (idx % 64)
+				}
+
+				if free != 0 {
+					found := c*chunkBits + w*64 + uint64(bits.TrailingZeros64(free))
+					if found < a.size {
+						return found, true
+					}
+					return 0, false
+				}
+			}
+		}
+
+		// The rest of the chunk is allocated, skip to the next one.
+		if c == last {
+			break
+		}
+		idx = (c + 1) * chunkBits
+		a.lowest = idx
+	}
+	return 0, false
+}
@@ -0,0 +1,210 @@
+package sequence
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// Ensure that the allocator implements the Bounded interface.
+func TestAllocatorInterface(t *testing.T) {
+	var _ Bounded = &Allocator{}
+}
+
+// Test that the lowest free value is always allocated.
+func TestAllocatorLowestFree(t *testing.T) {
+	a, err := NewAllocator(1024, 1033)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for expected := uint64(1024); expected <= 1033; expected++ {
+		if val, err := a.Allocate(); err != nil || val != expected {
+			t.Fatalf("expected %d got %d (%v)", expected, val, err)
+		}
+	}
+
+	if _, err := a.Allocate(); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("expected exhausted error got %v", err)
+	}
+
+	for _, val := range []uint64{1030, 1025, 1027} {
+		if err := a.Release(val); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if a.Allocated() != 7 || a.Remaining() != 3 {
+		t.Errorf("expected 7 allocated and 3 remaining got %d and %d", a.Allocated(), a.Remaining())
+	}
+
+	for _, expected := range []uint64{1025, 1027, 1030} {
+		if val, err := a.Allocate(); err != nil || val != expected {
+			t.Errorf("expected %d got %d (%v)", expected, val, err)
+		}
+	}
+}
+
+// Test that double releases and values outside the range are rejected.
+func TestAllocatorReleaseErrors(t *testing.T) {
+	a, _ := NewAllocator(2, 20, 2)
+	if _, err := a.Allocate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !a.IsAllocated(2) || a.IsAllocated(4) {
+		t.Error("unexpected allocation state")
+	}
+
+	if err := a.Release(2); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := a.Release(2); err != ErrNotAllocated {
+		t.Errorf("expected double release error got %v", err)
+	}
+
+	if err := a.Release(4); err != ErrNotAllocated {
+		t.Errorf("expected not allocated error got %v", err)
+	}
+
+	for _, val := range []uint64{0, 3, 22} {
+		if err := a.Release(val); err == nil || err == ErrNotAllocated {
+			t.Errorf("expected out of range error for %d got %v", val, err)
+		}
+
+		if a.IsAllocated(val) {
+			t.Errorf("value %d out of range is allocated", val)
+		}
+	}
+
+	if err := new(Allocator).Release(1); err == nil {
+		t.Error("released a value from an uninitialized allocator")
+	}
+
+	if _, err := new(Allocator).Allocate(); err == nil {
+		t.Error("allocated a value from an uninitialized allocator")
+	}
+
+	if err := a.Init(); err == nil {
+		t.Error("re-initialized an allocator")
+	}
+}
+
+// Test allocating and releasing values across chunk boundaries.
+func TestAllocatorChunks(t *testing.T) {
+	a, _ := NewAllocator(3*chunkBits + 100)
+	for i := 0; i < 3*chunkBits+100; i++ {
+		if _, err := a.Allocate(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// Every chunk is full so no bitmaps are stored.
+	for c, chunk := range a.chunks {
+		if chunk != fullChunk {
+			t.Errorf("chunk %d is not compressed", c)
+		}
+	}
+
+	released := []uint64{chunkBits * 2, chunkBits + 1, chunkBits*3 + 100, 1, chunkBits * 3}
+	for _, val := range released {
+		if err := a.Release(val); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if a.IsAllocated(val) {
+			t.Errorf("released value %d is allocated", val)
+		}
+	}
+
+	for _, expected := range []uint64{1, chunkBits + 1, chunkBits * 2, chunkBits * 3, chunkBits*3 + 100} {
+		if val, err := a.Allocate(); err != nil || val != expected {
+			t.Errorf("expected %d got %d (%v)", expected, val, err)
+		}
+	}
+
+	// Releasing every value of a chunk frees its bitmap.
+	for val := uint64(1); val <= chunkBits; val++ {
+		a.Release(val)
+	}
+
+	if _, ok := a.chunks[0]; ok {
+		t.Error("empty chunk was not removed")
+	}
+}
+
+// Test that an allocator over the full range is cheap.
+func TestAllocatorFullRange(t *testing.T) {
+	a, _ := NewAllocator()
+	for i := 0; i < 10000; i++ {
+		a.Allocate()
+	}
+
+	stored := 0
+	for _, chunk := range a.chunks {
+		if chunk != fullChunk {
+			stored++
+		}
+	}
+
+	if stored != 1 {
+		t.Errorf("expected only the partial chunk to be stored got %d chunks", stored)
+	}
+
+	if a.Remaining() != MaximumBound-10000 {
+		t.Errorf("unexpected remaining values %d", a.Remaining())
+	}
+
+	// Values at the top of the range can be allocated and released.
+	b, err := NewAllocator(1<<62, MaximumBound, 1<<62)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	b.Allocate()
+	b.Allocate()
+	if val, err := b.Allocate(); err != nil || val != 3<<62 {
+		t.Errorf("expected %d got %d (%v)", uint64(3<<62), val, err)
+	}
+
+	if err := b.Release(3 << 62); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+// Test concurrent allocations and releases.
+func TestAllocatorConcurrent(t *testing.T) {
+	a, _ := NewAllocator(1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				val, err := a.Allocate()
+				if err != nil {
+					t.Error(err.Error())
+					return
+				}
+
+				if j%2 == 0 {
+					if err := a.Release(val); err != nil {
+						t.Error(err.Error())
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if a.Allocated() != 500 {
+		t.Errorf("expected 500 values allocated got %d", a.Allocated())
+	}
+
+	// The allocated values are the lowest values.
+	if val, _ := a.Allocate(); val != 501 {
+		t.Errorf("expected 501 got %d", val)
+	}
+}
@@ -0,0 +1,204 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Alteration describes changes to the step and bounds of an initialized
+// sequence, in the same way as PostgreSQL's ALTER SEQUENCE. Fields that are
+// zero are left unchanged.
+type Alteration struct {
+	Increment uint64 // The new step between values
+	MinValue  uint64 // The new minimum value
+	MaxValue  uint64 // The new maximum value
+}
+
+// Alter changes the step and bounds of the sequence without losing its
+// position: once it has been started, Next continues from the current value
+// by the new step. If the sequence has not been started, it starts from the
+// new minimum value instead. An error is returned, and the sequence is not
+// modified, if the new configuration is invalid, including a step greater
+// than the minimum value as with Init, or if the current value is
+// not within the new bounds, e.g. if the maximum value is lowered below a
+// value that has already been issued. Use RestartWith to move the sequence
+// into the new bounds.
+func (s *Sequence) Alter(changes Alteration) error {
+	return s.AlterAs(changes, "")
+}
+
+// AlterAs alters the sequence as described by Alter, attributing the audit
+// event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *Sequence) AlterAs(changes Alteration, actor string) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	altered, err := s.altered(changes)
+	if err != nil {
+		return err
+	}
+
+	old := s.current
+	s.current, s.increment = altered.current, altered.increment
+	s.minvalue, s.maxvalue = altered.minvalue, altered.maxvalue
+	return s.record(AuditAlter, actor, old, s.current)
+}
+
+// RestartWith restarts the sequence so that the next value returned by Next
+// is val, in the same way as PostgreSQL's ALTER SEQUENCE RESTART WITH. Like
+// Restart, it may violate the monotonically increasing rule and should be
+// used with care. An error is returned if val is not within the bounds.
+func (s *Sequence) RestartWith(val uint64) error {
+	return s.RestartWithAs(val, "")
+}
+
+// RestartWithAs restarts the sequence as described by RestartWith, attributing
+// the audit event to the actor instead of the actor given to SetAudit unless
+// it is empty.
+func (s *Sequence) RestartWithAs(val uint64, actor string) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	if val < s.minvalue || val > s.maxvalue {
+		return fmt.Errorf("cannot restart with %d: value must be between %d and %d", val, s.minvalue, s.maxvalue)
+	}
+
+	// Ensure unsigned subtraction won't lead to a problem.
+	current, ok := stepBefore(val, s.increment)
+	if !ok {
+		return fmt.Errorf("cannot restart with %d: value must be greater than or equal to the step", val)
+	}
+
+	old := s.current
+	s.current = current
+	return s.record(AuditRestart, actor, old, s.current)
+}
+
+// Returns a copy of the sequence with the changes applied, or an error if
+// the changes are not valid for the current state of the sequence.
+func (s *Sequence) altered(changes Alteration) (*Sequence, error) {
+	altered := &Sequence{
+		current:     s.current,
+		increment:   s.increment,
+		minvalue:    s.minvalue,
+		maxvalue:    s.maxvalue,
+		initialized: true,
+	}
+
+	if changes.Increment != 0 {
+		altered.increment = changes.Increment
+	}
+
+	if changes.MinValue != 0 {
+		altered.minvalue = changes.MinValue
+	}
+
+	if changes.MaxValue != 0 {
+		altered.maxvalue = changes.MaxValue
+	}
+
+	// Validate the configuration the same way as Init.
+	if altered.maxvalue < altered.minvalue {
+		return nil, errors.New("for a positive increment, the maximum value must be greater than or equal to the minimum value")
+	}
+
+	if altered.minvalue < MinimumBound || altered.maxvalue > MaximumBound {
+		return nil, errors.New("part of the range is out of bounds for positive increment")
+	}
+
+	// Ensure the sequence can still be restarted from the new minimum value.
+	first, ok := stepBefore(altered.minvalue, altered.increment)
+	if !ok {
+		return nil, errors.New("the minimum value must be greater than or equal to the step")
+	}
+
+	if s.current < s.minvalue {
+		// No values have been issued, so start from the new minimum value.
+		altered.current = first
+		return altered, nil
+	}
+
+	if altered.current < altered.minvalue {
+		return nil, fmt.Errorf("cannot raise the minimum value above the current value %d", altered.current)
+	}
+
+	if altered.current > altered.maxvalue {
+		return nil, fmt.Errorf("cannot lower the maximum value below the current value %d", altered.current)
+	}
+	return altered, nil
+}
+
+// Alter changes the step and bounds of the sequence as described by
+// Sequence.Alter. The current value, step and bounds are changed together
+// under a lock that excludes the other methods that change the sequence, so
+// no value is issued by a concurrent Next with a mix of the old and new
+// configuration.
+func (s *AtomicSequence) Alter(changes Alteration) error {
+	return s.AlterAs(changes, "")
+}
+
+// AlterAs alters the sequence as described by Alter, attributing the audit
+// event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *AtomicSequence) AlterAs(changes Alteration, actor string) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	s.alter.Lock()
+	snapshot := Sequence{
+		current:   atomic.LoadUint64(&s.current),
+		increment: atomic.LoadUint64(&s.increment),
+		minvalue:  atomic.LoadUint64(&s.minvalue),
+		maxvalue:  atomic.LoadUint64(&s.maxvalue),
+	}
+
+	altered, err := snapshot.altered(changes)
+	if err != nil {
+		s.alter.Unlock()
+		return err
+	}
+
+	atomic.StoreUint64(&s.current, altered.current)
+	atomic.StoreUint64(&s.increment, altered.increment)
+	atomic.StoreUint64(&s.minvalue, altered.minvalue)
+	atomic.StoreUint64(&s.maxvalue, altered.maxvalue)
+	s.alter.Unlock()
+
+	return (*Sequence)(s).record(AuditAlter, actor, snapshot.current, altered.current)
+}
+
+// RestartWith restarts the sequence so that the next value returned by Next
+// is val, as described by Sequence.RestartWith. It is done in an atomic way.
+func (s *AtomicSequence) RestartWith(val uint64) error {
+	return s.RestartWithAs(val, "")
+}
+
+// RestartWithAs restarts the sequence as described by RestartWith, attributing
+// the audit event to the actor instead of the actor given to SetAudit unless
+// it is empty.
+func (s *AtomicSequence) RestartWithAs(val uint64, actor string) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	minvalue, maxvalue := atomic.LoadUint64(&s.minvalue), atomic.LoadUint64(&s.maxvalue)
+	if val < minvalue || val > maxvalue {
+		return fmt.Errorf("cannot restart with %d: value must be between %d and %d", val, minvalue, maxvalue)
+	}
+
+	current, ok := stepBefore(val, atomic.LoadUint64(&s.increment))
+	if !ok {
+		return fmt.Errorf("cannot restart with %d: value must be greater than or equal to the step", val)
+	}
+
+	old := atomic.SwapUint64(&s.current, current)
+	return (*Sequence)(s).record(AuditRestart, actor, old, current)
+}
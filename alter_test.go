@@ -0,0 +1,323 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// Test altering a sequence that has not been started.
+func TestAlterUnstarted(t *testing.T) {
+	seq, _ := New(100)
+	if err := seq.Alter(Alteration{Increment: 5, MinValue: 10}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, expected := range []uint64{10, 15, 20} {
+		if val, err := seq.Next(); err != nil || val != expected {
+			t.Errorf("expected next value %d, got %d (%v)", expected, val, err)
+		}
+	}
+
+	if minvalue, maxvalue, increment := seq.Bounds(); minvalue != 10 || maxvalue != 100 || increment != 5 {
+		t.Errorf("unexpected bounds %d, %d, %d", minvalue, maxvalue, increment)
+	}
+}
+
+// Test that altering a started sequence keeps its position.
+func TestAlter(t *testing.T) {
+	seq, _ := New(10, 1000)
+	for i := 0; i < 5; i++ {
+		seq.Next()
+	}
+
+	if err := seq.Alter(Alteration{Increment: 10, MaxValue: 2000}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 24 {
+		t.Errorf("expected next value 24, got %d (%v)", val, err)
+	}
+
+	if rem := seq.Remaining(); rem != 197 {
+		t.Errorf("expected 197 remaining values, got %d", rem)
+	}
+
+	// The alteration must be reflected in the dumped state.
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	vals := make(map[string]uint64)
+	json.Unmarshal(data, &vals)
+	if vals["current"] != 24 || vals["increment"] != 10 || vals["minvalue"] != 10 || vals["maxvalue"] != 2000 {
+		t.Errorf("unexpected dumped state %s", data)
+	}
+}
+
+// Test that an exhausted sequence continues after its old maximum value.
+func TestAlterExhausted(t *testing.T) {
+	seq, _ := New(3)
+	for i := 0; i < 5; i++ {
+		seq.Next()
+	}
+
+	if err := seq.Alter(Alteration{MaxValue: 10}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 4 {
+		t.Errorf("expected next value 4, got %d (%v)", val, err)
+	}
+}
+
+// Test that invalid alterations are rejected without modifying the sequence.
+func TestBadAlter(t *testing.T) {
+	if err := new(Sequence).Alter(Alteration{Increment: 2}); err == nil {
+		t.Error("altered an uninitialized sequence")
+	}
+
+	// Unstarted sequences must be able to start from the new minimum value.
+	unstarted, _ := New(10, 100)
+	if err := unstarted.Alter(Alteration{Increment: 20}); err == nil {
+		t.Error("altered the step of an unstarted sequence to more than its minimum value")
+	}
+
+	seq, _ := New(10, 100)
+	for i := 0; i < 10; i++ {
+		seq.Next()
+	}
+
+	tests := []Alteration{
+		{Increment: 11},
+		{MinValue: 50, MaxValue: 40},
+		{MaxValue: MaximumBound + 1},
+		{MinValue: 20},
+		{MaxValue: 18},
+	}
+
+	for _, tt := range tests {
+		if err := seq.Alter(tt); err == nil {
+			t.Errorf("expected alteration %+v to fail", tt)
+		}
+	}
+
+	if val, _ := seq.Current(); val != 19 {
+		t.Errorf("expected current value 19, got %d", val)
+	}
+
+	if minvalue, maxvalue, increment := seq.Bounds(); minvalue != 10 || maxvalue != 100 || increment != 1 {
+		t.Errorf("unexpected bounds %d, %d, %d", minvalue, maxvalue, increment)
+	}
+
+	// Started sequences must still be able to restart after an alteration.
+	started, _ := New()
+	started.Next()
+	if err := started.Alter(Alteration{Increment: 1000}); err == nil {
+		t.Error("altered the step of a started sequence to more than its minimum value")
+	}
+
+	if err := started.Restart(); err != nil {
+		t.Errorf("could not restart after a rejected alteration: %s", err)
+	}
+
+	aseq, _ := NewAtomic()
+	aseq.Next()
+	if err := aseq.Alter(Alteration{Increment: 1000}); err == nil {
+		t.Error("altered the step of a started atomic sequence to more than its minimum value")
+	}
+}
+
+// Test restarting a sequence at a specific value.
+func TestRestartWith(t *testing.T) {
+	seq, _ := New(10, 100, 10)
+	seq.Next()
+	seq.Next()
+
+	if err := seq.RestartWith(50); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 50 {
+		t.Errorf("expected next value 50, got %d (%v)", val, err)
+	}
+
+	// Values below the current value can be restarted with.
+	if err := seq.RestartWith(10); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 10 {
+		t.Errorf("expected next value 10, got %d (%v)", val, err)
+	}
+
+	for _, val := range []uint64{0, 9, 101} {
+		if err := seq.RestartWith(val); err == nil {
+			t.Errorf("restarted with %d outside of the bounds", val)
+		}
+	}
+
+	if err := new(Sequence).RestartWith(1); err == nil {
+		t.Error("restarted an uninitialized sequence")
+	}
+}
+
+// Test that alterations are audited.
+func TestAlterAudit(t *testing.T) {
+	sink := new(memorySink)
+	seq, _ := New(2, MaximumBound)
+	seq.SetAudit(sink, "")
+	seq.Next()
+
+	seq.Alter(Alteration{Increment: 2})
+	seq.Alter(Alteration{MaxValue: 0})
+	seq.RestartWith(7)
+
+	expected := []AuditEvent{
+		{Op: AuditAlter, Old: 2, New: 2},
+		{Op: AuditAlter, Old: 2, New: 2},
+		{Op: AuditRestart, Old: 2, New: 5},
+	}
+
+	if len(sink.events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(sink.events))
+	}
+
+	for i, event := range sink.events {
+		if event.Op != expected[i].Op || event.Old != expected[i].Old || event.New != expected[i].New {
+			t.Errorf("expected event %d to be %+v, got %+v", i, expected[i], event)
+		}
+	}
+}
+
+// Test altering an atomic sequence.
+func TestAtomicAlter(t *testing.T) {
+	seq, _ := NewAtomic(10, 1000)
+	for i := 0; i < 5; i++ {
+		seq.Next()
+	}
+
+	if err := seq.Alter(Alteration{Increment: 10}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 24 {
+		t.Errorf("expected next value 24, got %d (%v)", val, err)
+	}
+
+	if err := seq.Alter(Alteration{MaxValue: 10}); err == nil {
+		t.Error("lowered the maximum value below the current value")
+	}
+
+	if err := seq.RestartWith(100); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 100 {
+		t.Errorf("expected next value 100, got %d (%v)", val, err)
+	}
+
+	if err := seq.RestartWith(1001); err == nil {
+		t.Error("restarted with a value outside of the bounds")
+	}
+}
+
+// Test that no value is issued twice while an atomic sequence is altered.
+func TestAtomicAlterConcurrent(t *testing.T) {
+	seq, _ := NewAtomic(3, MaximumBound, 1)
+
+	var wg sync.WaitGroup
+	results := make([][]uint64, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				val, err := seq.Next()
+				if err != nil {
+					t.Error(err.Error())
+					return
+				}
+				results[i] = append(results[i], val)
+			}
+		}(i)
+	}
+
+	for i := uint64(1); i <= 100; i++ {
+		if err := seq.Alter(Alteration{Increment: i%3 + 1}); err != nil {
+			t.Error(err.Error())
+		}
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for _, vals := range results {
+		for _, val := range vals {
+			if seen[val] {
+				t.Fatalf("value %d was issued twice", val)
+			}
+			seen[val] = true
+		}
+	}
+}
+
+// Test that no value is issued past a maximum value that is lowered while an
+// atomic sequence is in use, so that the sequence can always be dumped.
+func TestAtomicAlterConcurrentMaxValue(t *testing.T) {
+	seq, _ := NewAtomic(1, MaximumBound)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if _, err := seq.Next(); err != nil {
+					if !errors.Is(err, ErrExhausted) {
+						t.Error(err.Error())
+						return
+					}
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	// Repeatedly stop the sequence at its current value, then check that no
+	// concurrent Next went past it before raising the maximum value again.
+	for i := 0; i < 10000; i++ {
+		current, _ := seq.Current()
+		if err := seq.Alter(Alteration{MaxValue: max(current, 1)}); err != nil {
+			continue
+		}
+		runtime.Gosched()
+
+		data, err := seq.Dump()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if err := new(Sequence).Load(data); err != nil {
+			t.Fatalf("value issued past the lowered maximum value: %s", err)
+		}
+
+		if err := seq.Alter(Alteration{MaxValue: MaximumBound}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
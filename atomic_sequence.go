@@ -1,6 +1,7 @@
 package sequence
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -53,9 +54,9 @@ func (s *AtomicSequence) Init(params ...uint64) error {
 	}
 	// If no parameters, create the default sequence.
 	if len(params) == 0 {
-		atomic.AddUint64(&s.increment, 1)
-		atomic.AddUint64(&s.minvalue, MinimumBound)
-		atomic.AddUint64(&s.maxvalue, MaximumBound)
+		atomic.StoreUint64(&s.increment, 1)
+		atomic.StoreUint64(&s.minvalue, MinimumBound)
+		atomic.StoreUint64(&s.maxvalue, MaximumBound)
 	}
 
 	// If a single parameter create a maximal bounding.
@@ -66,9 +67,9 @@ func (s *AtomicSequence) Init(params ...uint64) error {
 			return errors.New("must specify a maximal value greater than 0")
 		}
 
-		atomic.AddUint64(&s.increment, 1)
-		atomic.AddUint64(&s.minvalue, MinimumBound)
-		atomic.AddUint64(&s.maxvalue, params[0])
+		atomic.StoreUint64(&s.increment, 1)
+		atomic.StoreUint64(&s.minvalue, MinimumBound)
+		atomic.StoreUint64(&s.maxvalue, params[0])
 	}
 
 	// If two parameters create a positive range.
@@ -81,9 +82,9 @@ func (s *AtomicSequence) Init(params ...uint64) error {
 			return errors.New("part of the range is out of bounds for positive increment")
 		}
 
-		atomic.AddUint64(&s.increment, 1)
-		atomic.AddUint64(&s.minvalue, params[0])
-		atomic.AddUint64(&s.maxvalue, params[1])
+		atomic.StoreUint64(&s.increment, 1)
+		atomic.StoreUint64(&s.minvalue, params[0])
+		atomic.StoreUint64(&s.maxvalue, params[1])
 	}
 
 	// If three parameters create a range with a new step.
@@ -114,9 +115,9 @@ func (s *AtomicSequence) Init(params ...uint64) error {
 			}
 		}
 
-		atomic.AddUint64(&s.increment, params[2])
-		atomic.AddUint64(&s.minvalue, params[0])
-		atomic.AddUint64(&s.maxvalue, params[1])
+		atomic.StoreUint64(&s.increment, params[2])
+		atomic.StoreUint64(&s.minvalue, params[0])
+		atomic.StoreUint64(&s.maxvalue, params[1])
 	}
 
 	// If more than three parameters then return an error.
@@ -125,33 +126,38 @@ func (s *AtomicSequence) Init(params ...uint64) error {
 	}
 
 	// Ensure unsigned subtraction won't lead to a problem.
-	if int(s.minvalue)-int(s.increment) < 0 {
-		return errors.New("the minimum value must be less than or equal to the step")
+	current, ok := stepBefore(atomic.LoadUint64(&s.minvalue), atomic.LoadUint64(&s.increment))
+	if !ok {
+		return errors.New("the minimum value must be greater than or equal to the step")
 	}
 
-	atomic.SwapUint64(&s.current, atomic.LoadUint64(&s.minvalue)-atomic.LoadUint64(&s.increment))
+	atomic.StoreUint64(&s.current, current)
 	s.initialized = true
 	return nil
 }
 
 // Next updates the state of the Sequence and return the next item in the
 // sequence. It will return an error if either the minimum or the maximal
-// value has been reached.
+// value has been reached. Once the maximal value has been reached the state
+// of the sequence is no longer modified.
 // It is done in an atomic way.
 func (s *AtomicSequence) Next() (uint64, error) {
-	atomic.AddUint64(&s.current, atomic.LoadUint64(&s.increment))
+	// Ensure that the sequence has been initialized.
+	if !s.initialized {
+		return 0, errors.New("sequence has not been initialized")
+	}
 
-	// Check for missed minimum condition
-	if atomic.LoadUint64(&s.current) < atomic.LoadUint64(&s.minvalue) {
-		return 0, errors.New("reached minimum bound of the sequence")
+	_, val, err := s.advance()
+	if err != nil {
+		return 0, err
 	}
 
-	// Check for reached maximum condition
-	if atomic.LoadUint64(&s.current) > atomic.LoadUint64(&s.maxvalue) {
-		return 0, errors.New("reached maximum bound of sequence")
+	// Check for missed minimum condition
+	if val < atomic.LoadUint64(&s.minvalue) {
+		return 0, fmt.Errorf("%w: reached minimum bound of the sequence", ErrExhausted)
 	}
 
-	return atomic.LoadUint64(&s.current), nil
+	return val, nil
 }
 
 // Restart the sequence by resetting the current value. This is the only
@@ -160,25 +166,52 @@ func (s *AtomicSequence) Next() (uint64, error) {
 // fail safe if required.
 // It is done in an atomic way.
 func (s *AtomicSequence) Restart() error {
+	return s.RestartAs("")
+}
+
+// RestartAs restarts the sequence as described by Restart, attributing the
+// audit event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *AtomicSequence) RestartAs(actor string) error {
 	// Ensure that the sequence has been initialized.
 	if !s.initialized {
 		return errors.New("sequence has not been initialized")
 	}
 
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
 	// Ensure unsigned subtraction won't lead to a problem.
-	if int(atomic.LoadUint64(&s.minvalue))-int(atomic.LoadUint64(&s.increment)) < 0 {
-		return errors.New("the minimum value must be less than or equal to the step")
+	current, ok := stepBefore(atomic.LoadUint64(&s.minvalue), atomic.LoadUint64(&s.increment))
+	if !ok {
+		return errors.New("the minimum value must be greater than or equal to the step")
 	}
 
 	// Set current based on the minvalue and the increment.
-	atomic.SwapUint64(&s.current, atomic.LoadUint64(&s.minvalue)-atomic.LoadUint64(&s.increment))
-	return nil
+	old := atomic.SwapUint64(&s.current, current)
+	return (*Sequence)(s).record(AuditRestart, actor, old, current)
 }
 
 // Update the sequence to the current value. If the update value violates the
-// monotonically increasing or decreasing rule, an error is returned.
+// monotonically increasing or decreasing rule or is greater than the maximum
+// value, an error is returned.
 // It is done in an atomic way.
 func (s *AtomicSequence) Update(val uint64) error {
+	return s.UpdateAs(val, "")
+}
+
+// UpdateAs updates the sequence as described by Update, attributing the audit
+// event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *AtomicSequence) UpdateAs(val uint64, actor string) error {
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	// maximum bound error
+	if val > atomic.LoadUint64(&s.maxvalue) {
+		return errors.New("cannot update sequence beyond its maximum value")
+	}
+
 	// monotonically increasing error
 	if atomic.LoadUint64(&s.increment) > 0 && val < atomic.LoadUint64(&s.current) {
 		return errors.New("cannot decrease monotonically increasing sequence")
@@ -190,8 +223,67 @@ func (s *AtomicSequence) Update(val uint64) error {
 	}
 
 	// Update the sequence.
-	atomic.SwapUint64(&s.current, val)
-	return nil
+	old := atomic.SwapUint64(&s.current, val)
+	return (*Sequence)(s).record(AuditUpdate, actor, old, val)
+}
+
+// NextContext returns the next value of the sequence unless the context is
+// done. The context is checked before the state of the sequence is updated.
+func (s *AtomicSequence) NextContext(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, WrapContextError(err)
+	}
+	return s.Next()
+}
+
+// ReserveContext reserves the next n values of the sequence unless the
+// context is done. The reservation is made with a single compare-and-swap so
+// that concurrent calls to Next or ReserveContext never interleave with the
+// reserved values. Either all n values are reserved or the state of the
+// sequence is unchanged and an error is returned.
+func (s *AtomicSequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, WrapContextError(err)
+	}
+
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	first, last, err := s.reserve(n)
+	if err != nil {
+		return nil, err
+	}
+	return reserved(first, last, atomic.LoadUint64(&s.increment)), nil
+}
+
+// Reserve the next n values of the sequence, returning the first and last
+// values of the reserved block. It is done in an atomic way; the caller must
+// hold the alter read lock if the sequence may be altered concurrently.
+func (s *AtomicSequence) reserve(n uint64) (first, last uint64, err error) {
+	if !s.initialized {
+		return 0, 0, errors.New("sequence has not been initialized")
+	}
+
+	if n == 0 {
+		return 0, 0, errors.New("must reserve at least one value")
+	}
+
+	for {
+		current := atomic.LoadUint64(&s.current)
+		increment := atomic.LoadUint64(&s.increment)
+		maxvalue := atomic.LoadUint64(&s.maxvalue)
+
+		last, ok := stepForward(current, n, increment)
+		if !ok || last > maxvalue {
+			rem := remaining(current, increment, maxvalue)
+			return 0, 0, fmt.Errorf("%w: cannot reserve %d values, only %d remaining in sequence", ErrExhausted, n, rem)
+		}
+
+		if atomic.CompareAndSwapUint64(&s.current, current, last) {
+			first, _ = stepForward(current, 1, increment)
+			return first, last, nil
+		}
+	}
 }
 
 // Current gives the current value of this sequence atomically.
@@ -207,31 +299,58 @@ func (s *AtomicSequence) Current() (uint64, error) {
 	return atomic.LoadUint64(&s.current), nil
 }
 
-// IsStarted does atomic checks to see if this sequence has already started.
+// IsStarted does atomic checks to see if this sequence has already started,
+// as described by Sequence.IsStarted.
 func (s *AtomicSequence) IsStarted() bool {
+	state := s.State()
+	return state == Active || state == Exhausted
+}
+
+// Bounds returns the minimum value, maximum value and increment of this
+// sequence atomically.
+func (s *AtomicSequence) Bounds() (minvalue, maxvalue, increment uint64) {
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	return atomic.LoadUint64(&s.minvalue), atomic.LoadUint64(&s.maxvalue), atomic.LoadUint64(&s.increment)
+}
+
+// Remaining returns the number of values that can still be returned by Next
+// before this sequence reaches its maximum bound.
+func (s *AtomicSequence) Remaining() uint64 {
 	if !s.initialized {
-		return false
+		return 0
 	}
-	return !(atomic.LoadUint64(&s.current) < atomic.LoadUint64(&s.minvalue)) &&
-		atomic.LoadUint64(&s.current) < atomic.LoadUint64(&s.maxvalue)
+
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	return remaining(atomic.LoadUint64(&s.current), atomic.LoadUint64(&s.increment), atomic.LoadUint64(&s.maxvalue))
 }
 
 // String returns a human readable representation of this sequence.
 func (s *AtomicSequence) String() string {
 	d := fmt.Sprintf("incremented by %d between %d and %d", atomic.LoadUint64(&s.increment),
 		atomic.LoadUint64(&s.minvalue), atomic.LoadUint64(&s.maxvalue))
-	if !s.IsStarted() {
+	switch s.State() {
+	case Active:
+		return fmt.Sprintf("Sequence at %d, %s", atomic.LoadUint64(&s.current), d)
+	case Exhausted:
+		return fmt.Sprintf("Exhausted Sequence at %d, %s", atomic.LoadUint64(&s.current), d)
+	default:
 		return fmt.Sprintf("Unstarted Sequence %s", d)
 	}
-	return fmt.Sprintf("Sequence at %d, %s", atomic.LoadUint64(&s.current), d)
 }
 
 // Dump uses atomic Loads to Marshal current data from a AtomicSequence into a JSON object
 func (s *AtomicSequence) Dump() ([]byte, error) {
-	if !s.IsStarted() {
-		return nil, errors.New("cannot dump an uninitialized or unstarted sequence")
+	if !s.initialized {
+		return nil, errors.New("cannot dump an uninitialized sequence")
 	}
 
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
 	data := make(map[string]uint64)
 	data["current"] = atomic.LoadUint64(&s.current)
 	data["increment"] = atomic.LoadUint64(&s.increment)
@@ -241,41 +360,39 @@ func (s *AtomicSequence) Dump() ([]byte, error) {
 	return json.Marshal(data)
 }
 
-// Load loads data from Dump. If the input is not the same as the output from Dump() then it will return a error.
+// Load loads data from Dump, validating it as described by Sequence.Load.
 func (s *AtomicSequence) Load(data []byte) error {
-	if s.initialized {
-		return errors.New("cannot load into an initialized sequence")
-	}
+	return s.load(data, false, "")
+}
 
-	vals := make(map[string]uint64)
-	if err := json.Unmarshal(data, &vals); err != nil {
-		return err
-	}
+// LoadAs loads the sequence as described by Load, attributing the audit event
+// to the actor instead of the actor given to SetAudit unless it is empty.
+func (s *AtomicSequence) LoadAs(data []byte, actor string) error {
+	return s.load(data, false, actor)
+}
 
-	if val, ok := vals["current"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	} else {
-		atomic.SwapUint64(&s.current, val)
-	}
+// LoadStrict loads data from Dump, rejecting unknown fields as described by
+// Sequence.LoadStrict.
+func (s *AtomicSequence) LoadStrict(data []byte) error {
+	return s.load(data, true, "")
+}
 
-	if val, ok := vals["increment"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	} else {
-		atomic.SwapUint64(&s.increment, val)
+// Load the sequence by the actor, rejecting unknown fields if strict.
+func (s *AtomicSequence) load(data []byte, strict bool, actor string) error {
+	if s.initialized {
+		return errors.New("cannot load into an initialized sequence")
 	}
 
-	if val, ok := vals["minvalue"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	} else {
-		atomic.SwapUint64(&s.minvalue, val)
+	loaded, err := parse(data, strict)
+	if err != nil {
+		return err
 	}
 
-	if val, ok := vals["maxvalue"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	} else {
-		atomic.SwapUint64(&s.maxvalue, val)
-	}
+	atomic.StoreUint64(&s.current, loaded.current)
+	atomic.StoreUint64(&s.increment, loaded.increment)
+	atomic.StoreUint64(&s.minvalue, loaded.minvalue)
+	atomic.StoreUint64(&s.maxvalue, loaded.maxvalue)
 
 	s.initialized = true
-	return nil
+	return (*Sequence)(s).record(AuditLoad, actor, 0, loaded.current)
 }
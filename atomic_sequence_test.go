@@ -461,7 +461,7 @@ func Test4ArgInitAtomic(t *testing.T) {
 // Test that sequence goes to the maximum value then errors
 func TestCeilingAtomic(t *testing.T) {
 	// Create a sequence right at the maximum bound.
-	seq := &AtomicSequence{MaximumBound - 1, 1, MinimumBound, MaximumBound, true}
+	seq := &AtomicSequence{current: MaximumBound - 1, increment: 1, minvalue: MinimumBound, maxvalue: MaximumBound, initialized: true}
 
 	idx, err := seq.Next()
 	if err != nil {
@@ -498,7 +498,7 @@ func TestIncrementAtomic(t *testing.T) {
 // Test that sequence goes to the maximum value then errors on increment
 func TestCeilingIncrementAtomic(t *testing.T) {
 	// Create a sequence right at the maximum bound.
-	seq := &AtomicSequence{MaximumBound - 1, 2, MinimumBound, MaximumBound, true}
+	seq := &AtomicSequence{current: MaximumBound - 1, increment: 2, minvalue: MinimumBound, maxvalue: MaximumBound, initialized: true}
 
 	jdx, err := seq.Next()
 	if err == nil {
@@ -569,6 +569,25 @@ func TestRangeAtomic(t *testing.T) {
 	}
 }
 
+// Test the bounds and remaining functionality
+func TestBoundsRemainingAtomic(t *testing.T) {
+	var _ Bounded = &AtomicSequence{}
+
+	seq, err := NewAtomic(10, 19)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if min, max, inc := seq.Bounds(); min != 10 || max != 19 || inc != 1 {
+		t.Errorf("unexpected bounds %d, %d, %d", min, max, inc)
+	}
+
+	seq.Next()
+	if rem := seq.Remaining(); rem != 9 {
+		t.Errorf("expected 9 remaining got %d", rem)
+	}
+}
+
 func TestIfAtomicIsSafeForConcurrentUse(t *testing.T) {
 	seq, err := NewAtomic()
 	if err != nil {
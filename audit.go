@@ -0,0 +1,160 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrAudit is wrapped by the errors returned when an operation could not be
+// recorded by an AuditSink. The operation itself has already been applied to
+// the sequence when this error is returned.
+var ErrAudit = errors.New("could not record audit event")
+
+// AuditOp is the type of a state-changing operation recorded in the audit log.
+type AuditOp string
+
+// The state-changing operations of a sequence that are audited. Next is not
+// audited since it does not violate the monotonicity of the sequence.
+const (
+	AuditUpdate   AuditOp = "update"
+	AuditRestart  AuditOp = "restart"
+	AuditLoad     AuditOp = "load"
+	AuditAlter    AuditOp = "alter"
+	AuditRollback AuditOp = "rollback"
+)
+
+// AuditEvent describes a state-changing operation on a sequence.
+type AuditEvent struct {
+	Op    AuditOp   `json:"op"`              // The type of operation
+	Old   uint64    `json:"old"`             // The current value before the operation
+	New   uint64    `json:"new"`             // The current value after the operation
+	Time  time.Time `json:"time"`            // When the operation was applied
+	Actor string    `json:"actor,omitempty"` // Who applied the operation, by default as given to SetAudit
+}
+
+// AuditSink records audit events, e.g. to a file or an external log service.
+// Record is called synchronously by the operation being audited, so sinks
+// that are slow should buffer events themselves.
+type AuditSink interface {
+	Record(event AuditEvent) error
+}
+
+// AuditFunc adapts an ordinary function to the AuditSink interface.
+type AuditFunc func(event AuditEvent) error
+
+// Record calls f(event).
+func (f AuditFunc) Record(event AuditEvent) error {
+	return f(event)
+}
+
+// The audit configuration of a sequence.
+type auditor struct {
+	sink  AuditSink // Where events are recorded
+	actor string    // Attributed to every event
+	clock Clock     // The source of time for event timestamps
+}
+
+// SetAudit records every Update, Restart, RestartWith, Alter and Load of the
+// sequence and the Rollback of its transactions to the sink, attributed to
+// the actor, e.g. the name of the service or user that owns the sequence. A
+// nil sink disables auditing. If the sink returns an error, the operation is
+// still applied and an error wrapping ErrAudit is returned by it.
+//
+// The actor is the default for every event; operations can be attributed to
+// the user that calls them with UpdateAs, RestartAs, RestartWithAs, AlterAs,
+// LoadAs and Tx.RollbackAs.
+func (s *Sequence) SetAudit(sink AuditSink, actor string) {
+	if sink == nil {
+		s.audit = nil
+		return
+	}
+	s.audit = &auditor{sink: sink, actor: actor, clock: systemClock{}}
+}
+
+// SetAudit records every state-changing operation of the sequence to the
+// sink, as described by Sequence.SetAudit. It must not be called concurrently
+// with other methods of the sequence.
+func (s *AtomicSequence) SetAudit(sink AuditSink, actor string) {
+	(*Sequence)(s).SetAudit(sink, actor)
+}
+
+// Record the operation by the actor, or by the actor given to SetAudit if it
+// is empty, to the audit sink, if any.
+func (s *Sequence) record(op AuditOp, actor string, old, val uint64) error {
+	if s.audit == nil {
+		return nil
+	}
+
+	if actor == "" {
+		actor = s.audit.actor
+	}
+
+	event := AuditEvent{Op: op, Old: old, New: val, Time: s.audit.clock.Now(), Actor: actor}
+	if err := s.audit.sink.Record(event); err != nil {
+		return fmt.Errorf("%w: %w", ErrAudit, err)
+	}
+	return nil
+}
+
+// JSONLinesSink is an AuditSink that writes each event as a line of JSON,
+// e.g. {"op":"restart","old":42,"new":0,"time":"2026-01-01T00:00:00Z"}. It is
+// safe for concurrent use, so one sink can be shared by several sequences.
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer // Where the events are written
+	f  *os.File  // The file opened by OpenJSONLinesSink, if any
+}
+
+// NewJSONLinesSink returns a sink that writes events to w.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+// OpenJSONLinesSink returns a sink that appends events to the file at path,
+// creating it if it does not exist. Each event is synced to disk before
+// Record returns. The sink should be closed when it is no longer needed.
+func OpenJSONLinesSink(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesSink{w: f, f: f}, nil
+}
+
+// Record writes the event as a single line of JSON.
+func (s *JSONLinesSink) Record(event AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	if s.f != nil {
+		return s.f.Sync()
+	}
+	return nil
+}
+
+// Close the file opened by OpenJSONLinesSink. It does nothing if the sink
+// was created by NewJSONLinesSink.
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,260 @@
+package sequence
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Records audit events in memory.
+type memorySink struct {
+	events []AuditEvent
+}
+
+func (m *memorySink) Record(event AuditEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+// Ensure that the sinks implement the AuditSink interface.
+func TestAuditSinkInterface(t *testing.T) {
+	var _ AuditSink = &JSONLinesSink{}
+	var _ AuditSink = AuditFunc(nil)
+}
+
+// Test that Update, Restart and Load are recorded but Next is not.
+func TestAudit(t *testing.T) {
+	clock := newMockClock()
+	sink := new(memorySink)
+
+	seq, _ := New()
+	seq.SetAudit(sink, "tester")
+	seq.audit.clock = clock
+
+	for i := 0; i < 10; i++ {
+		seq.Next()
+	}
+
+	if len(sink.events) != 0 {
+		t.Fatalf("expected Next not to be audited, got %d events", len(sink.events))
+	}
+
+	if err := seq.Update(42); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	clock.Advance(time.Minute)
+	if err := seq.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []AuditEvent{
+		{Op: AuditUpdate, Old: 10, New: 42, Time: clock.Now().Add(-time.Minute), Actor: "tester"},
+		{Op: AuditRestart, Old: 42, New: 0, Time: clock.Now(), Actor: "tester"},
+	}
+
+	if len(sink.events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(sink.events))
+	}
+
+	for i, event := range sink.events {
+		if event != expected[i] {
+			t.Errorf("expected event %d to be %+v, got %+v", i, expected[i], event)
+		}
+	}
+
+	// Failed operations are not recorded.
+	seq.Next()
+	if err := seq.Update(0); err == nil {
+		t.Error("expected update to a lower value to fail")
+	}
+
+	if len(sink.events) != 2 {
+		t.Errorf("expected failed update not to be audited, got %d events", len(sink.events))
+	}
+
+	// A nil sink disables auditing.
+	seq.SetAudit(nil, "")
+	if err := seq.Update(100); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(sink.events) != 2 {
+		t.Errorf("expected no events once auditing is disabled, got %d events", len(sink.events))
+	}
+}
+
+// Test that loading a sequence is recorded.
+func TestAuditLoad(t *testing.T) {
+	seq, _ := New()
+	seq.Update(42)
+	data, _ := seq.Dump()
+
+	sink := new(memorySink)
+	for _, loader := range []interface {
+		Load([]byte) error
+		SetAudit(AuditSink, string)
+	}{new(Sequence), new(AtomicSequence)} {
+		loader.SetAudit(sink, "loader")
+		if err := loader.Load(data); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sink.events))
+	}
+
+	for _, event := range sink.events {
+		if event.Op != AuditLoad || event.Old != 0 || event.New != 42 || event.Actor != "loader" {
+			t.Errorf("unexpected load event %+v", event)
+		}
+	}
+}
+
+// Test that operations are attributed to the actor given to each call.
+func TestAuditActor(t *testing.T) {
+	data, _ := (&Sequence{current: 5, increment: 1, minvalue: 1, maxvalue: 100, initialized: true}).Dump()
+
+	type auditable interface {
+		SetAudit(AuditSink, string)
+		LoadAs([]byte, string) error
+		UpdateAs(uint64, string) error
+		RestartAs(string) error
+		RestartWithAs(uint64, string) error
+		AlterAs(Alteration, string) error
+		Begin() (*Tx, error)
+	}
+
+	for _, seq := range []auditable{new(Sequence), new(AtomicSequence)} {
+		sink := new(memorySink)
+		seq.SetAudit(sink, "owner")
+
+		if err := seq.LoadAs(data, "loader"); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		seq.UpdateAs(10, "alice")
+		seq.AlterAs(Alteration{MaxValue: 50}, "bob")
+		seq.RestartWithAs(20, "")
+		seq.RestartAs("carol")
+
+		tx, _ := seq.Begin()
+		tx.Next()
+		tx.RollbackAs("dave")
+
+		expected := []string{"loader", "alice", "bob", "owner", "carol", "dave"}
+		if len(sink.events) != len(expected) {
+			t.Fatalf("expected %d events, got %d", len(expected), len(sink.events))
+		}
+
+		for i, event := range sink.events {
+			if event.Actor != expected[i] {
+				t.Errorf("expected %s event to be attributed to %q, got %q", event.Op, expected[i], event.Actor)
+			}
+		}
+	}
+}
+
+// Test that the atomic sequence records the value it replaced.
+func TestAtomicAudit(t *testing.T) {
+	sink := new(memorySink)
+
+	seq, _ := NewAtomic()
+	seq.SetAudit(sink, "")
+	seq.Next()
+	seq.Next()
+
+	if err := seq.Update(10); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := seq.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sink.events))
+	}
+
+	if event := sink.events[0]; event.Op != AuditUpdate || event.Old != 2 || event.New != 10 {
+		t.Errorf("unexpected update event %+v", event)
+	}
+
+	if event := sink.events[1]; event.Op != AuditRestart || event.Old != 10 || event.New != 0 {
+		t.Errorf("unexpected restart event %+v", event)
+	}
+}
+
+// Test that sink errors are returned after the operation is applied.
+func TestAuditError(t *testing.T) {
+	failure := errors.New("disk full")
+
+	seq, _ := New()
+	seq.SetAudit(AuditFunc(func(AuditEvent) error { return failure }), "")
+
+	err := seq.Update(42)
+	if !errors.Is(err, ErrAudit) || !errors.Is(err, failure) {
+		t.Errorf("expected error wrapping ErrAudit and the sink error, got %v", err)
+	}
+
+	if val, _ := seq.Current(); val != 42 {
+		t.Errorf("expected update to be applied, current is %d", val)
+	}
+}
+
+// Test that the JSON-lines sink appends one event per line to a file.
+func TestJSONLinesSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	for i := 0; i < 2; i++ {
+		sink, err := OpenJSONLinesSink(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		seq, _ := New()
+		seq.SetAudit(sink, "writer")
+		seq.Update(uint64(i + 1))
+		seq.Restart()
+
+		if err := sink.Close(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("could not parse line %q: %s", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	expected := []AuditOp{AuditUpdate, AuditRestart, AuditUpdate, AuditRestart}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events in the file, got %d", len(expected), len(events))
+	}
+
+	for i, event := range events {
+		if event.Op != expected[i] || event.Actor != "writer" || event.Time.IsZero() {
+			t.Errorf("unexpected event %d: %+v", i, event)
+		}
+	}
+
+	if events[2].New != 2 {
+		t.Errorf("expected the second file to be appended, got %+v", events[2])
+	}
+}
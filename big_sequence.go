@@ -0,0 +1,411 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// BigMaximumBound returns the largest default value of a BigSequence, which
+// mirrors MaximumBound for a 128-bit integer:
+// 340,282,366,920,938,463,463,374,607,431,768,211,454. Larger bounds may be
+// passed to NewBig or Init explicitly.
+func BigMaximumBound() *big.Int {
+	return maxuint128.big()
+}
+
+// The default maximum bound of a BigSequence is the largest uint128 - 1.
+var maxuint128 = uint128{hi: ^uint64(0), lo: ^uint64(0) - 1}
+
+// BigSequence is an arbitrary-precision Sequence for ranges that exceed the
+// uint64 MaximumBound, such as cryptographic nonce counters or 128-bit
+// identifier spaces. It has the same Init, Next, Update, Restart, Dump and
+// Load contract as Sequence but uses *big.Int values, and therefore does not
+// implement the Incrementer interface.
+//
+// Internally, sequences whose bounds and step fit in 128 bits use a fixed
+// size uint128 representation with carry-checked arithmetic; larger sequences
+// fall back to math/big arithmetic. The values returned by a BigSequence are
+// always copies and can be modified by the caller.
+//
+// BigSequences are serialized with decimal string encoded values so that no
+// precision is lost by JSON parsers that decode numbers as floats:
+//
+//     {"current":"10","increment":"1","maxvalue":"340282366920938463463374607431768211454","minvalue":"1"}
+type BigSequence struct {
+	fast        bool     // Use the uint128 fast path rather than math/big
+	current     uint128  // The current value of the sequence (fast path)
+	increment   uint128  // The value to increment by (fast path)
+	minvalue    uint128  // The minimum value of the counter (fast path)
+	maxvalue    uint128  // The max value of the counter (fast path)
+	bcurrent    *big.Int // The current value of the sequence (math/big)
+	bincrement  *big.Int // The value to increment by (math/big)
+	bminvalue   *big.Int // The minimum value of the counter (math/big)
+	bmaxvalue   *big.Int // The max value of the counter (math/big)
+	initialized bool     // Flag that indicates if the sequence has been initialized.
+}
+
+// NewBig constructs a BigSequence, interpreting the params as described by
+// BigSequence.Init. By default the sequence counts by 1 from 1 to
+// BigMaximumBound.
+func NewBig(params ...*big.Int) (*BigSequence, error) {
+	seq := new(BigSequence)
+	err := seq.Init(params...)
+	return seq, err
+}
+
+//===========================================================================
+// BigSequence Interaction Methods
+//===========================================================================
+
+// Init a sequence with reasonable defaults based on the number and order of
+// the params, exactly as described by Sequence.Init:
+//
+//     seq.Init()                   // count by 1 from 1 to BigMaximumBound
+//     seq.Init(max)                // count by 1 from 1 until max
+//     seq.Init(min, max)           // count by 1 from min until max
+//     seq.Init(min, max, step)     // count by step from min until max
+//
+// Both endpoints of these ranges are inclusive and none of the params may be
+// nil. Init returns an error if the sequence is already initialized or if the
+// params do not describe a valid range.
+func (s *BigSequence) Init(params ...*big.Int) error {
+	if s.initialized {
+		return errors.New("cannot re-initialize a sequence object")
+	}
+
+	for _, param := range params {
+		if param == nil {
+			return errors.New("cannot initialize a sequence with a nil value")
+		}
+	}
+
+	one := big.NewInt(MinimumBound)
+	var minvalue, maxvalue, increment *big.Int
+
+	switch len(params) {
+	case 0:
+		increment, minvalue, maxvalue = one, one, BigMaximumBound()
+	case 1:
+		if params[0].Cmp(one) < 0 {
+			return errors.New("must specify a maximal value greater than 0")
+		}
+		increment, minvalue, maxvalue = one, one, params[0]
+	case 2:
+		if params[1].Cmp(params[0]) < 0 {
+			return errors.New("for a positive increment, the maximum value must be greater than or equal to the minimum value")
+		}
+
+		if params[0].Cmp(one) < 0 {
+			return errors.New("part of the range is out of bounds for positive increment")
+		}
+		increment, minvalue, maxvalue = one, params[0], params[1]
+	case 3:
+		if params[2].Sign() <= 0 {
+			return errors.New("must have a positive step to increment by")
+		}
+
+		if params[1].Cmp(params[0]) < 0 {
+			return errors.New("for a positive increment, the second value must be greater than or equal to the first value")
+		}
+
+		if params[0].Cmp(one) < 0 {
+			return errors.New("part of the range is out of bounds for positive increment")
+		}
+		increment, minvalue, maxvalue = params[2], params[0], params[1]
+	default:
+		return errors.New("too many arguments specified")
+	}
+
+	// Ensure unsigned subtraction won't lead to a problem.
+	if minvalue.Cmp(increment) < 0 {
+		return errors.New("the minimum value must be greater than or equal to the step")
+	}
+
+	current := new(big.Int).Sub(minvalue, increment)
+	s.set(current, increment, minvalue, maxvalue)
+	s.initialized = true
+	return nil
+}
+
+// Next updates the state of the sequence and returns the next item in the
+// sequence. It will return an error if the maximal value has been reached.
+func (s *BigSequence) Next() (*big.Int, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+
+	if s.fast {
+		next, overflow := s.current.add(s.increment)
+		if overflow || next.cmp(s.maxvalue) > 0 {
+			return nil, fmt.Errorf("%w: reached maximum bound of sequence", ErrExhausted)
+		}
+
+		s.current = next
+		return next.big(), nil
+	}
+
+	next := new(big.Int).Add(s.bcurrent, s.bincrement)
+	if next.Cmp(s.bmaxvalue) > 0 {
+		return nil, fmt.Errorf("%w: reached maximum bound of sequence", ErrExhausted)
+	}
+
+	s.bcurrent = next
+	return new(big.Int).Set(next), nil
+}
+
+// Restart the sequence by resetting the current value. This is the only
+// method that allows direct manipulation of the sequence state which violates
+// the monotonically increasing rule. Use with care and as a fail safe if
+// required.
+func (s *BigSequence) Restart() error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	// Ensure unsigned subtraction won't lead to a problem.
+	if s.fast {
+		current, borrow := s.minvalue.sub(s.increment)
+		if borrow {
+			return errors.New("the minimum value must be greater than or equal to the step")
+		}
+		s.current = current
+		return nil
+	}
+
+	current := new(big.Int).Sub(s.bminvalue, s.bincrement)
+	if current.Sign() < 0 {
+		return errors.New("the minimum value must be greater than or equal to the step")
+	}
+	s.bcurrent = current
+	return nil
+}
+
+// Update the sequence to the current value. If the update value violates the
+// monotonically increasing rule, an error is returned.
+func (s *BigSequence) Update(val *big.Int) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	if val == nil || val.Sign() < 0 {
+		return errors.New("cannot update sequence to a negative or nil value")
+	}
+
+	if s.fast {
+		v, ok := toUint128(val)
+		if !ok || v.cmp(s.maxvalue) > 0 {
+			return errors.New("cannot update sequence beyond its maximum bound")
+		}
+
+		if v.cmp(s.current) < 0 {
+			return errors.New("cannot decrease monotonically increasing sequence")
+		}
+
+		s.current = v
+		return nil
+	}
+
+	if val.Cmp(s.bmaxvalue) > 0 {
+		return errors.New("cannot update sequence beyond its maximum bound")
+	}
+
+	if val.Cmp(s.bcurrent) < 0 {
+		return errors.New("cannot decrease monotonically increasing sequence")
+	}
+
+	s.bcurrent = new(big.Int).Set(val)
+	return nil
+}
+
+//===========================================================================
+// BigSequence State Methods
+//===========================================================================
+
+// Current value of the sequence. Current will return an error if the sequence
+// has not been started or initialized.
+func (s *BigSequence) Current() (*big.Int, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+
+	if !s.IsStarted() {
+		return nil, errors.New("sequence has not been started")
+	}
+
+	current, _, _, _ := s.values()
+	return current, nil
+}
+
+// IsStarted returns true if the sequence has issued a value, including if it
+// has been exhausted, as described by Sequence.IsStarted.
+func (s *BigSequence) IsStarted() bool {
+	state := s.State()
+	return state == Active || state == Exhausted
+}
+
+// State returns the current state of the sequence as described by State.
+func (s *BigSequence) State() State {
+	if !s.initialized {
+		return Uninitialized
+	}
+
+	if s.fast {
+		if s.current.cmp(s.minvalue) < 0 {
+			return Ready
+		}
+
+		if next, overflow := s.current.add(s.increment); overflow || next.cmp(s.maxvalue) > 0 {
+			return Exhausted
+		}
+		return Active
+	}
+
+	if s.bcurrent.Cmp(s.bminvalue) < 0 {
+		return Ready
+	}
+
+	if new(big.Int).Add(s.bcurrent, s.bincrement).Cmp(s.bmaxvalue) > 0 {
+		return Exhausted
+	}
+	return Active
+}
+
+// String returns a human readable representation of the sequence.
+func (s *BigSequence) String() string {
+	current, increment, minvalue, maxvalue := s.values()
+	d := fmt.Sprintf("incremented by %s between %s and %s", increment, minvalue, maxvalue)
+	switch s.State() {
+	case Active:
+		return fmt.Sprintf("Sequence at %s, %s", current, d)
+	case Exhausted:
+		return fmt.Sprintf("Exhausted Sequence at %s, %s", current, d)
+	default:
+		return fmt.Sprintf("Unstarted Sequence %s", d)
+	}
+}
+
+//===========================================================================
+// BigSequence Serialization Methods
+//===========================================================================
+
+// Dump the sequence into a JSON binary representation for the current state
+// as described by Sequence.Dump. Values are encoded as decimal strings to
+// ensure no precision is lost.
+func (s *BigSequence) Dump() ([]byte, error) {
+	if !s.initialized {
+		return nil, errors.New("cannot dump an uninitialized sequence")
+	}
+
+	current, increment, minvalue, maxvalue := s.values()
+	data := make(map[string]string)
+	data["current"] = current.String()
+	data["increment"] = increment.String()
+	data["minvalue"] = minvalue.String()
+	data["maxvalue"] = maxvalue.String()
+
+	return json.Marshal(data)
+}
+
+// Load an uninitialized sequence from a JSON binary representation of the
+// state of another sequence as exported by Dump. The state is validated as
+// described by Sequence.Load.
+func (s *BigSequence) Load(data []byte) error {
+	return s.load(data, false)
+}
+
+// LoadStrict loads the sequence as described by Load, rejecting unknown
+// fields as described by Sequence.LoadStrict.
+func (s *BigSequence) LoadStrict(data []byte) error {
+	return s.load(data, true)
+}
+
+// Load the sequence, rejecting unknown fields if strict.
+func (s *BigSequence) load(data []byte, strict bool) error {
+	if s.initialized {
+		return errors.New("cannot load into an initialized sequence")
+	}
+
+	vals, err := decodeFields[string](data, strict, stateFields)
+	if err != nil {
+		return err
+	}
+
+	parsed := make(map[string]*big.Int, len(stateFields))
+	for _, key := range stateFields {
+		num, ok := new(big.Int).SetString(vals[key], 10)
+		if !ok || num.Sign() < 0 {
+			return fmt.Errorf("could not parse %s value %q", key, vals[key])
+		}
+		parsed[key] = num
+	}
+
+	current, increment := parsed["current"], parsed["increment"]
+	minvalue, maxvalue := parsed["minvalue"], parsed["maxvalue"]
+
+	// Validate the same invariants as Sequence.Load.
+	if increment.Sign() == 0 {
+		return &InvariantError{Field: "increment", Err: ErrZeroStep}
+	}
+
+	if minvalue.Cmp(big.NewInt(MinimumBound)) < 0 {
+		return &InvariantError{Field: "minvalue", Err: ErrOutOfBounds}
+	}
+
+	if minvalue.Cmp(maxvalue) > 0 {
+		return &InvariantError{Field: "maxvalue", Err: ErrEmptyRange}
+	}
+
+	if minvalue.Cmp(increment) < 0 {
+		return &InvariantError{Field: "increment", Err: ErrStepTooLarge}
+	}
+
+	if current.Cmp(new(big.Int).Sub(minvalue, increment)) < 0 || current.Cmp(maxvalue) > 0 {
+		return &InvariantError{Field: "current", Err: ErrOutOfRange}
+	}
+
+	s.set(current, increment, minvalue, maxvalue)
+	s.initialized = true
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// Set the state of the sequence, selecting the uint128 fast path if every
+// value fits in 128 bits.
+func (s *BigSequence) set(current, increment, minvalue, maxvalue *big.Int) {
+	var ok [4]bool
+	s.current, ok[0] = toUint128(current)
+	s.increment, ok[1] = toUint128(increment)
+	s.minvalue, ok[2] = toUint128(minvalue)
+	s.maxvalue, ok[3] = toUint128(maxvalue)
+
+	s.fast = ok[0] && ok[1] && ok[2] && ok[3]
+	if s.fast {
+		s.bcurrent, s.bincrement, s.bminvalue, s.bmaxvalue = nil, nil, nil, nil
+		return
+	}
+
+	s.bcurrent = new(big.Int).Set(current)
+	s.bincrement = new(big.Int).Set(increment)
+	s.bminvalue = new(big.Int).Set(minvalue)
+	s.bmaxvalue = new(big.Int).Set(maxvalue)
+}
+
+// Returns copies of the state of the sequence as math/big values.
+func (s *BigSequence) values() (current, increment, minvalue, maxvalue *big.Int) {
+	if s.fast {
+		return s.current.big(), s.increment.big(), s.minvalue.big(), s.maxvalue.big()
+	}
+
+	if !s.initialized {
+		zero := new(big.Int)
+		return zero, zero, zero, zero
+	}
+
+	return new(big.Int).Set(s.bcurrent), new(big.Int).Set(s.bincrement),
+		new(big.Int).Set(s.bminvalue), new(big.Int).Set(s.bmaxvalue)
+}
@@ -0,0 +1,329 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// Helper to parse a decimal string into a big.Int for tests.
+func bigint(s string) *big.Int {
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("could not parse %q", s))
+	}
+	return b
+}
+
+//===========================================================================
+// BigSequence Tests
+//===========================================================================
+
+// Test the creation of a default BigSequence object.
+func TestNewDefaultBig(t *testing.T) {
+	seq, err := NewBig()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !seq.fast {
+		t.Error("default sequence is not using the uint128 fast path")
+	}
+
+	if seq.maxvalue.big().String() != "340282366920938463463374607431768211454" {
+		t.Errorf("maximum value not initialized correctly: %s", seq.maxvalue.big())
+	}
+
+	for i := int64(1); i < 1000; i++ {
+		idx, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx.Int64() != i {
+			t.Fatalf("mismatch counter value during +1 sequence: %s", idx)
+		}
+	}
+}
+
+// Test that the fast path carries across the 64-bit boundary.
+func TestBigCarry(t *testing.T) {
+	seq, err := NewBig(bigint("18446744073709551614"), bigint("18446744073709551620"), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []string{"18446744073709551614", "18446744073709551616", "18446744073709551618", "18446744073709551620"}
+	for _, e := range expected {
+		idx, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx.String() != e {
+			t.Fatalf("expected %s got %s", e, idx)
+		}
+	}
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("did not raise error after going over maximum bound")
+	}
+}
+
+// Test that a sequence at the 128-bit ceiling does not overflow.
+func TestBigCeiling(t *testing.T) {
+	max := new(big.Int).Lsh(big.NewInt(1), 128)
+	max.Sub(max, big.NewInt(1))
+
+	seq, err := NewBig(new(big.Int).Sub(max, big.NewInt(4)), max, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !seq.fast {
+		t.Fatal("128-bit sequence is not using the uint128 fast path")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := seq.Next(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("did not raise error instead of overflowing 128 bits")
+	}
+}
+
+// Test the math/big fallback beyond 128 bits.
+func TestBigFallback(t *testing.T) {
+	min := new(big.Int).Lsh(big.NewInt(1), 200)
+	max := new(big.Int).Add(min, big.NewInt(10))
+
+	seq, err := NewBig(min, max, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if seq.fast {
+		t.Fatal("200-bit sequence is using the uint128 fast path")
+	}
+
+	for i := int64(0); i <= 10; i += 5 {
+		idx, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if expected := new(big.Int).Add(min, big.NewInt(i)); idx.Cmp(expected) != 0 {
+			t.Fatalf("expected %s got %s", expected, idx)
+		}
+
+		// Modifying the returned value must not modify the sequence.
+		idx.SetInt64(0)
+	}
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("did not raise error after going over maximum bound")
+	}
+
+	if err := seq.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if idx, _ := seq.Next(); idx.Cmp(min) != 0 {
+		t.Errorf("restarted sequence began at %s", idx)
+	}
+}
+
+// Test bad Init arguments.
+func TestBigBadInit(t *testing.T) {
+	tests := [][]*big.Int{
+		{big.NewInt(0)},
+		{nil},
+		{big.NewInt(10), big.NewInt(1)},
+		{big.NewInt(0), big.NewInt(10)},
+		{big.NewInt(1), big.NewInt(10), big.NewInt(0)},
+		{big.NewInt(1), big.NewInt(10), big.NewInt(-1)},
+		{big.NewInt(1), big.NewInt(10), big.NewInt(2)},
+		{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)},
+	}
+
+	for _, params := range tests {
+		if _, err := NewBig(params...); err == nil {
+			t.Errorf("expected error initializing with %v", params)
+		}
+	}
+
+	seq, _ := NewBig()
+	if err := seq.Init(); err == nil {
+		t.Error("sequence allowed re-initialization")
+	}
+}
+
+// Test the update, current and is started functionality.
+func TestBigUpdate(t *testing.T) {
+	seq, err := NewBig()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := seq.Current(); err == nil || seq.IsStarted() {
+		t.Error("unstarted sequence is started")
+	}
+
+	val := bigint("100000000000000000000000000")
+	if err := seq.Update(val); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if idx, err := seq.Current(); err != nil || idx.Cmp(val) != 0 {
+		t.Errorf("current is %s not %s", idx, val)
+	}
+
+	if idx, _ := seq.Next(); idx.String() != "100000000000000000000000001" {
+		t.Errorf("unexpected next value after update: %s", idx)
+	}
+
+	if err := seq.Update(val); err == nil {
+		t.Error("allowed decrease of a monotonically increasing sequence")
+	}
+
+	if err := seq.Update(new(big.Int).Lsh(big.NewInt(1), 130)); err == nil {
+		t.Error("allowed update beyond the maximum bound")
+	}
+
+	if err := seq.Update(big.NewInt(-1)); err == nil {
+		t.Error("allowed update to a negative value")
+	}
+}
+
+// An example of the human readable state of a big sequence.
+func ExampleBigSequence_String() {
+	seq, _ := NewBig()
+
+	fmt.Println(seq)
+
+	seq.Next()
+	fmt.Println(seq)
+
+	// Output:
+	// Unstarted Sequence incremented by 1 between 1 and 340282366920938463463374607431768211454
+	// Sequence at 1, incremented by 1 between 1 and 340282366920938463463374607431768211454
+}
+
+//===========================================================================
+// BigSequence Serialization
+//===========================================================================
+
+// Test that both representations round trip through Dump and Load.
+func TestBigSerialization(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 300)
+
+	for _, params := range [][]*big.Int{nil, {big.NewInt(1), huge}} {
+		seqa, err := NewBig(params...)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if _, err := new(BigSequence).Dump(); err == nil {
+			t.Error("dumped an uninitialized sequence")
+		}
+
+		seqa.Update(bigint("99999999999999999999999999999"))
+		data, err := seqa.Dump()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		// All values must be JSON strings to avoid loss of precision.
+		vals := make(map[string]string)
+		if err := json.Unmarshal(data, &vals); err != nil {
+			t.Fatalf("values are not string encoded: %s", err)
+		}
+
+		seqb := new(BigSequence)
+		if err := seqb.Load(data); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if seqb.fast != seqa.fast || seqb.String() != seqa.String() {
+			t.Errorf("loaded sequence %s does not match %s", seqb, seqa)
+		}
+
+		if err := seqb.Load(data); err == nil {
+			t.Error("loaded into an initialized sequence")
+		}
+	}
+
+	bad := []string{
+		`{"current":"1"}`,
+		`{"current":1,"increment":1,"minvalue":1,"maxvalue":10}`,
+		`{"current":"foo","increment":"1","minvalue":"1","maxvalue":"10"}`,
+		`{"current":"-1","increment":"1","minvalue":"1","maxvalue":"10"}`,
+		`{"current":"1","increment":"0","minvalue":"1","maxvalue":"10"}`,
+		`{"current":"1","increment":"1","minvalue":"0","maxvalue":"10"}`,
+		`{"current":"5","increment":"1","minvalue":"10","maxvalue":"5"}`,
+		`{"current":"11","increment":"1","minvalue":"1","maxvalue":"10"}`,
+		`{"current":"1","increment":"2","minvalue":"4","maxvalue":"10"}`,
+		`{"current":"0","increment":"5","minvalue":"1","maxvalue":"100"}`,
+	}
+
+	for _, data := range bad {
+		if err := new(BigSequence).Load([]byte(data)); err == nil {
+			t.Errorf("loaded improperly formatted data %s", data)
+		}
+	}
+
+	if err := new(BigSequence).Load([]byte(bad[len(bad)-1])); !errors.Is(err, ErrStepTooLarge) {
+		t.Errorf("expected ErrStepTooLarge got %v", err)
+	}
+}
+
+// Test that restart does not wrap around if the step exceeds the minimum.
+func TestBigRestartBorrow(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	for _, increment := range []*big.Int{big.NewInt(5), huge} {
+		seq := new(BigSequence)
+		seq.set(big.NewInt(1), increment, big.NewInt(1), new(big.Int).Add(huge, huge))
+		seq.initialized = true
+
+		if err := seq.Restart(); err == nil {
+			t.Errorf("restarted a sequence with a step of %s greater than its minimum", increment)
+		}
+	}
+}
+
+// Write a big sequence to disk to be loaded later.
+func ExampleBigSequence_Dump() {
+	seq, _ := NewBig()
+
+	for i := 0; i < 10; i++ {
+		seq.Next()
+	}
+
+	data, _ := seq.Dump()
+	fmt.Println(string(data))
+
+	// Output:
+	// {"current":"10","increment":"1","maxvalue":"340282366920938463463374607431768211454","minvalue":"1"}
+}
+
+//===========================================================================
+// Benchmarks
+//===========================================================================
+
+func BenchmarkBigSequence(b *testing.B) {
+	seq, err := NewBig()
+	if err != nil {
+		b.Error(err.Error())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seq.Next()
+	}
+	b.ReportAllocs()
+}
@@ -0,0 +1,223 @@
+// Package checkdigit appends check digits to sequence values so that typos in
+// customer-facing identifiers such as account numbers are caught before they
+// are looked up. A Generator draws values from any sequence.Incrementer and
+// appends the check digits of a configurable Algorithm:
+//
+//     seq, err := sequence.New()
+//     gen := checkdigit.New(seq, checkdigit.Verhoeff)
+//     id, err := gen.Next() // "15"
+//
+//     err = checkdigit.Validate(checkdigit.Verhoeff, id)
+//     idx, err := checkdigit.Strip(checkdigit.Verhoeff, id) // 1
+//
+// The following algorithms are provided, all of which detect every single
+// digit error:
+//
+//     Luhn          the credit card algorithm; misses the 09 <-> 90 transposition
+//     Verhoeff      detects all adjacent transpositions
+//     Damm          detects all adjacent transpositions
+//     ISO7064Mod11  ISO 7064 MOD 11-2, whose check character may be X
+//     ISO7064Mod97  ISO 7064 MOD 97-10, which appends two check digits
+package checkdigit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Algorithm computes the check characters of a string of decimal digits.
+type Algorithm interface {
+	Compute(payload string) (string, error) // Returns the check characters to append to the payload
+	Size() int                              // Returns the number of check characters
+	String() string                         // Returns the name of the algorithm
+}
+
+// The check digit algorithms provided by this package.
+var (
+	Luhn         Algorithm = luhn{}
+	Verhoeff     Algorithm = verhoeff{}
+	Damm         Algorithm = damm{}
+	ISO7064Mod11 Algorithm = mod11{}
+	ISO7064Mod97 Algorithm = mod97{}
+)
+
+// Converts a payload into its digits, returning an error if it is empty or
+// contains any characters other than decimal digits.
+func decimal(payload string) ([]int, error) {
+	if payload == "" {
+		return nil, errors.New("cannot compute the check digit of an empty payload")
+	}
+
+	digits := make([]int, len(payload))
+	for i := 0; i < len(payload); i++ {
+		if payload[i] < '0' || payload[i] > '9' {
+			return nil, fmt.Errorf("payload %q contains a non-decimal character", payload)
+		}
+		digits[i] = int(payload[i] - '0')
+	}
+	return digits, nil
+}
+
+//===========================================================================
+// Luhn
+//===========================================================================
+
+type luhn struct{}
+
+func (luhn) Compute(payload string) (string, error) {
+	digits, err := decimal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	// Double every second digit starting with the rightmost digit of the
+	// payload, since the check digit will be appended to its right.
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return string(rune('0' + (10-sum%10)%10)), nil
+}
+
+func (luhn) Size() int      { return 1 }
+func (luhn) String() string { return "Luhn" }
+
+//===========================================================================
+// Verhoeff
+//===========================================================================
+
+// The multiplication table of the dihedral group D5.
+var verhoeffD = [10][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+	{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+	{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+	{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+	{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+	{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+	{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+	{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+	{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+}
+
+// The permutation table, applied according to the position of a digit.
+var verhoeffP = [8][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+	{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+	{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+	{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+	{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+	{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+	{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+}
+
+// The multiplicative inverses of D5.
+var verhoeffInv = [10]int{0, 4, 3, 2, 1, 5, 6, 7, 8, 9}
+
+type verhoeff struct{}
+
+func (verhoeff) Compute(payload string) (string, error) {
+	digits, err := decimal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	c := 0
+	for i := range digits {
+		c = verhoeffD[c][verhoeffP[(i+1)%8][digits[len(digits)-1-i]]]
+	}
+	return string(rune('0' + verhoeffInv[c])), nil
+}
+
+func (verhoeff) Size() int      { return 1 }
+func (verhoeff) String() string { return "Verhoeff" }
+
+//===========================================================================
+// Damm
+//===========================================================================
+
+// A totally anti-symmetric quasigroup of order 10.
+var dammTable = [10][10]int{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+type damm struct{}
+
+func (damm) Compute(payload string) (string, error) {
+	digits, err := decimal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	interim := 0
+	for _, d := range digits {
+		interim = dammTable[interim][d]
+	}
+	return string(rune('0' + interim)), nil
+}
+
+func (damm) Size() int      { return 1 }
+func (damm) String() string { return "Damm" }
+
+//===========================================================================
+// ISO 7064
+//===========================================================================
+
+type mod11 struct{}
+
+func (mod11) Compute(payload string) (string, error) {
+	digits, err := decimal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	p := 0
+	for _, d := range digits {
+		p = (p + d) * 2 % 11
+	}
+
+	if check := (12 - p) % 11; check != 10 {
+		return string(rune('0' + check)), nil
+	}
+	return "X", nil
+}
+
+func (mod11) Size() int      { return 1 }
+func (mod11) String() string { return "ISO 7064 MOD 11-2" }
+
+type mod97 struct{}
+
+func (mod97) Compute(payload string) (string, error) {
+	digits, err := decimal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	// The check digits are chosen so that the payload followed by the check
+	// digits is congruent to 1 mod 97.
+	r := 0
+	for _, d := range digits {
+		r = (r*10 + d) % 97
+	}
+	r = r * 100 % 97
+	return fmt.Sprintf("%02d", 98-r), nil
+}
+
+func (mod97) Size() int      { return 2 }
+func (mod97) String() string { return "ISO 7064 MOD 97-10" }
@@ -0,0 +1,108 @@
+package checkdigit
+
+import (
+	"fmt"
+	"testing"
+)
+
+var algorithms = []Algorithm{Luhn, Verhoeff, Damm, ISO7064Mod11, ISO7064Mod97}
+
+// Test the check characters of published examples of each algorithm.
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		alg     Algorithm
+		payload string
+		check   string
+	}{
+		{Luhn, "7992739871", "3"},
+		{Luhn, "0", "0"},
+		{Verhoeff, "236", "3"},
+		{Verhoeff, "1", "5"},
+		{Damm, "572", "4"},
+		{Damm, "0", "0"},
+		{ISO7064Mod11, "000000021825009", "7"},
+		{ISO7064Mod11, "000000021694233", "X"},
+		{ISO7064Mod97, "794", "44"},
+		{ISO7064Mod97, "1", "95"},
+		{ISO7064Mod97, "0", "98"},
+	}
+
+	for _, tc := range tests {
+		check, err := tc.alg.Compute(tc.payload)
+		if err != nil {
+			t.Errorf("%s could not compute check of %q: %s", tc.alg, tc.payload, err)
+			continue
+		}
+
+		if check != tc.check {
+			t.Errorf("expected %s check of %q to be %q got %q", tc.alg, tc.payload, tc.check, check)
+		}
+
+		if len(check) != tc.alg.Size() {
+			t.Errorf("%s returned %d check characters instead of %d", tc.alg, len(check), tc.alg.Size())
+		}
+	}
+}
+
+// Test that payloads that are not decimal digits are rejected.
+func TestComputeErrors(t *testing.T) {
+	for _, alg := range algorithms {
+		for _, payload := range []string{"", "12a4", "-1", "1 2", "١٢"} {
+			if _, err := alg.Compute(payload); err == nil {
+				t.Errorf("%s computed check of bad payload %q", alg, payload)
+			}
+		}
+	}
+}
+
+// Exhaustively test that every single digit error and every adjacent
+// transposition of every four digit payload and its check characters is
+// detected, with the exception of the 09 <-> 90 transposition for Luhn.
+func TestDetection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping exhaustive check digit test in short mode")
+	}
+
+	for _, alg := range algorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			for n := 0; n < 10000; n++ {
+				id, err := Append(alg, fmt.Sprintf("%04d", n))
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+
+				if err := Validate(alg, id); err != nil {
+					t.Fatalf("could not validate %q: %s", id, err)
+				}
+
+				for i := 0; i < len(id); i++ {
+					// Single digit errors.
+					for d := byte('0'); d <= '9'; d++ {
+						if d == id[i] {
+							continue
+						}
+
+						typo := id[:i] + string(d) + id[i+1:]
+						if Validate(alg, typo) == nil {
+							t.Fatalf("did not detect single digit error %q -> %q", id, typo)
+						}
+					}
+
+					// Adjacent transpositions.
+					if i+1 == len(id) || id[i] == id[i+1] {
+						continue
+					}
+
+					if alg == Luhn && (id[i:i+2] == "09" || id[i:i+2] == "90") {
+						continue
+					}
+
+					typo := id[:i] + string(id[i+1]) + string(id[i]) + id[i+2:]
+					if Validate(alg, typo) == nil {
+						t.Fatalf("did not detect transposition %q -> %q", id, typo)
+					}
+				}
+			}
+		})
+	}
+}
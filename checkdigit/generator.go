@@ -0,0 +1,97 @@
+package checkdigit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bbengfort/sequence"
+)
+
+// ErrInvalid is returned by Validate and Strip when the check characters of
+// an identifier do not match its payload, e.g. because it was mistyped.
+var ErrInvalid = errors.New("invalid check digit")
+
+// Generator draws values from an Incrementer and appends check digits to
+// their decimal representation. A Generator is as safe for concurrent use as
+// the Incrementer it draws from.
+type Generator struct {
+	inc sequence.Incrementer // The sequence values are drawn from
+	alg Algorithm            // The algorithm used to compute check digits
+}
+
+// New returns a Generator of identifiers from the Incrementer with check
+// digits computed by the Algorithm.
+func New(inc sequence.Incrementer, alg Algorithm) *Generator {
+	return &Generator{inc: inc, alg: alg}
+}
+
+// Next returns the next value of the sequence with check digits appended.
+func (g *Generator) Next() (string, error) {
+	val, err := g.inc.Next()
+	if err != nil {
+		return "", err
+	}
+	return g.Format(val)
+}
+
+// Format appends check digits to the decimal representation of the value.
+func (g *Generator) Format(val uint64) (string, error) {
+	return Append(g.alg, strconv.FormatUint(val, 10))
+}
+
+// Parse validates the identifier and returns the sequence value it contains.
+func (g *Generator) Parse(id string) (uint64, error) {
+	return Strip(g.alg, id)
+}
+
+// Append computes the check characters of the decimal payload and returns the
+// payload with the check characters appended.
+func Append(alg Algorithm, payload string) (string, error) {
+	check, err := alg.Compute(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + check, nil
+}
+
+// Validate returns nil if the identifier ends with the correct check
+// characters for its payload according to the algorithm. ErrInvalid is
+// returned if the check characters do not match.
+func Validate(alg Algorithm, id string) error {
+	_, err := split(alg, id)
+	return err
+}
+
+// Strip validates the identifier and returns the sequence value that
+// precedes its check characters.
+func Strip(alg Algorithm, id string) (uint64, error) {
+	payload, err := split(alg, id)
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse identifier %q: %w", id, err)
+	}
+	return val, nil
+}
+
+// Validate the identifier and split off its payload.
+func split(alg Algorithm, id string) (string, error) {
+	if len(id) <= alg.Size() {
+		return "", fmt.Errorf("identifier %q is too short for %s check digits", id, alg)
+	}
+
+	payload := id[:len(id)-alg.Size()]
+	check, err := alg.Compute(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if check != id[len(id)-alg.Size():] {
+		return "", ErrInvalid
+	}
+	return payload, nil
+}
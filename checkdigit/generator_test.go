@@ -0,0 +1,83 @@
+package checkdigit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bbengfort/sequence"
+)
+
+// Test generating identifiers from a sequence and parsing them back.
+func TestGenerator(t *testing.T) {
+	for _, alg := range algorithms {
+		seq, _ := sequence.New(1000)
+		gen := New(seq, alg)
+
+		for i := uint64(1); i <= 1000; i++ {
+			id, err := gen.Next()
+			if err != nil {
+				t.Fatalf("%s: %s", alg, err)
+			}
+
+			if err := Validate(alg, id); err != nil {
+				t.Fatalf("%s: generated invalid identifier %q", alg, id)
+			}
+
+			if val, err := gen.Parse(id); err != nil || val != i {
+				t.Fatalf("%s: expected %q to parse as %d got %d (%v)", alg, id, i, val, err)
+			}
+		}
+
+		if _, err := gen.Next(); !errors.Is(err, sequence.ErrExhausted) {
+			t.Errorf("%s: expected exhausted error got %v", alg, err)
+		}
+	}
+}
+
+// Test formatting specific values.
+func TestGeneratorFormat(t *testing.T) {
+	gen := New(nil, Luhn)
+	if id, err := gen.Format(7992739871); err != nil || id != "79927398713" {
+		t.Errorf("unexpected identifier %q (%v)", id, err)
+	}
+
+	gen = New(nil, ISO7064Mod97)
+	if id, err := gen.Format(sequence.MaximumBound); err != nil || len(id) != 22 {
+		t.Errorf("unexpected identifier %q (%v)", id, err)
+	}
+}
+
+// Test validating and stripping bad identifiers.
+func TestValidateStrip(t *testing.T) {
+	if err := Validate(Luhn, "79927398710"); err != ErrInvalid {
+		t.Errorf("expected invalid error got %v", err)
+	}
+
+	for _, id := range []string{"", "7", "79a27398713"} {
+		if err := Validate(Luhn, id); err == nil || err == ErrInvalid {
+			t.Errorf("expected malformed error for %q got %v", id, err)
+		}
+	}
+
+	if err := Validate(ISO7064Mod97, "44"); err == nil {
+		t.Error("validated an identifier without a payload")
+	}
+
+	if val, err := Strip(ISO7064Mod11, "0000000218250097"); err != nil || val != 21825009 {
+		t.Errorf("unexpected value %d (%v)", val, err)
+	}
+
+	if _, err := Strip(ISO7064Mod11, "000000021825009X"); err != ErrInvalid {
+		t.Errorf("expected invalid error got %v", err)
+	}
+
+	// Payloads that overflow a sequence value are valid but cannot be stripped.
+	id, _ := Append(Damm, "99999999999999999999")
+	if err := Validate(Damm, id); err != nil {
+		t.Errorf("could not validate %q: %s", id, err)
+	}
+
+	if _, err := Strip(Damm, id); err == nil {
+		t.Error("stripped a value larger than a uint64")
+	}
+}
@@ -0,0 +1,120 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Base62 encodes values with the digits followed by the upper and lower case
+// letters, producing at most 11 characters for any uint64 value.
+var Base62 = mustBase("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+// Crockford encodes values with Crockford's base32 alphabet, which excludes
+// the letters I, L, O and U. Values are encoded in upper case; when decoding,
+// lower case letters are accepted, I and L are read as 1, O is read as 0 and
+// hyphens are ignored so that ids can be read aloud and typed by hand.
+var Crockford Codec = crockford{mustBase("0123456789ABCDEFGHJKMNPQRSTVWXYZ")}
+
+// Base encodes values as numbers in the base of the length of its alphabet.
+type Base struct {
+	alphabet string    // The digits of the base
+	index    [256]int8 // The value of each digit, -1 if not in the alphabet
+}
+
+// NewBase returns a codec for the alphabet, which must contain at least 2 and
+// at most 64 unique ASCII characters.
+func NewBase(alphabet string) (*Base, error) {
+	if len(alphabet) < 2 || len(alphabet) > 64 {
+		return nil, errors.New("alphabet must contain between 2 and 64 characters")
+	}
+
+	b := &Base{alphabet: alphabet}
+	for i := range b.index {
+		b.index[i] = -1
+	}
+
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 {
+			return nil, fmt.Errorf("alphabet contains non-ASCII character at position %d", i)
+		}
+
+		if b.index[c] >= 0 {
+			return nil, fmt.Errorf("alphabet contains duplicate character %q", c)
+		}
+		b.index[c] = int8(i)
+	}
+	return b, nil
+}
+
+func mustBase(alphabet string) *Base {
+	b, err := NewBase(alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Alphabet returns the digits of the base.
+func (b *Base) Alphabet() string {
+	return b.alphabet
+}
+
+// Encode the value; zero is encoded as the first character of the alphabet.
+func (b *Base) Encode(val uint64) (string, error) {
+	base := uint64(len(b.alphabet))
+
+	var buf [64]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = b.alphabet[val%base]
+		if val /= base; val == 0 {
+			return string(buf[i:]), nil
+		}
+	}
+}
+
+// Decode a value, returning an error if the string is empty, contains
+// characters that are not in the alphabet, has leading zero digits or
+// overflows a uint64.
+func (b *Base) Decode(s string) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("cannot decode an empty string")
+	}
+
+	if len(s) > 1 && s[0] == b.alphabet[0] {
+		return 0, fmt.Errorf("cannot decode %q with leading zero digits", s)
+	}
+
+	base := uint64(len(b.alphabet))
+	var val uint64
+	for i := 0; i < len(s); i++ {
+		digit := b.index[s[i]]
+		if digit < 0 {
+			return 0, fmt.Errorf("cannot decode %q: invalid character %q", s, s[i])
+		}
+
+		hi, lo := bits.Mul64(val, base)
+		lo, carry := bits.Add64(lo, uint64(digit), 0)
+		if hi != 0 || carry != 0 {
+			return 0, fmt.Errorf("cannot decode %q: value overflows uint64", s)
+		}
+		val = lo
+	}
+	return val, nil
+}
+
+// Crockford's base32 with lenient decoding.
+type crockford struct {
+	*Base
+}
+
+// Normalizes a hand-typed id before decoding.
+var crockfordReplacer = strings.NewReplacer("-", "", "I", "1", "L", "1", "O", "0")
+
+func (c crockford) Decode(s string) (uint64, error) {
+	return c.Base.Decode(crockfordReplacer.Replace(strings.ToUpper(s)))
+}
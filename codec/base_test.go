@@ -0,0 +1,86 @@
+package codec
+
+import (
+	"testing"
+)
+
+// Test encoding and decoding known values.
+func TestBase(t *testing.T) {
+	tests := []struct {
+		codec Codec
+		val   uint64
+		enc   string
+	}{
+		{Base62, 0, "0"},
+		{Base62, 61, "z"},
+		{Base62, 62, "10"},
+		{Base62, 1000000, "4C92"},
+		{Base62, ^uint64(0), "LygHa16AHYF"},
+		{Crockford, 0, "0"},
+		{Crockford, 31, "Z"},
+		{Crockford, 32, "10"},
+		{Crockford, 1234, "16J"},
+		{Crockford, ^uint64(0), "FZZZZZZZZZZZZ"},
+	}
+
+	for _, tc := range tests {
+		if enc, err := tc.codec.Encode(tc.val); err != nil || enc != tc.enc {
+			t.Errorf("expected %d to encode as %q got %q (%v)", tc.val, tc.enc, enc, err)
+		}
+
+		if val, err := tc.codec.Decode(tc.enc); err != nil || val != tc.val {
+			t.Errorf("expected %q to decode as %d got %d (%v)", tc.enc, tc.val, val, err)
+		}
+	}
+}
+
+// Test that values round trip through custom bases.
+func TestNewBase(t *testing.T) {
+	for _, alphabet := range []string{"01", "0123456789abcdef", Shuffle(Base62.Alphabet(), []byte("secret"))} {
+		b, err := NewBase(alphabet)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for _, val := range []uint64{0, 1, 2, 255, 1 << 40, ^uint64(0)} {
+			enc, _ := b.Encode(val)
+			if dec, err := b.Decode(enc); err != nil || dec != val {
+				t.Errorf("expected %q to decode as %d got %d (%v)", enc, val, dec, err)
+			}
+		}
+	}
+
+	for _, alphabet := range []string{"", "0", "0120", "01é"} {
+		if _, err := NewBase(alphabet); err == nil {
+			t.Errorf("created base with bad alphabet %q", alphabet)
+		}
+	}
+}
+
+// Test that strings Encode could not have produced are rejected.
+func TestBaseDecodeErrors(t *testing.T) {
+	for _, s := range []string{"", "00", "01", "a-b", "LygHa16AHYG", "100000000000"} {
+		if val, err := Base62.Decode(s); err == nil {
+			t.Errorf("decoded bad string %q as %d", s, val)
+		}
+	}
+
+	for _, s := range []string{"", "U", "G0000000000000", "*"} {
+		if val, err := Crockford.Decode(s); err == nil {
+			t.Errorf("decoded bad string %q as %d", s, val)
+		}
+	}
+}
+
+// Test that Crockford decoding tolerates typos.
+func TestCrockfordLenient(t *testing.T) {
+	for _, s := range []string{"16J", "16j", "I6J", "l6j", "1-6-J"} {
+		if val, err := Crockford.Decode(s); err != nil || val != 1234 {
+			t.Errorf("expected %q to decode as 1234 got %d (%v)", s, val, err)
+		}
+	}
+
+	if val, err := Crockford.Decode("1O"); err != nil || val != 32 {
+		t.Errorf("expected 1O to decode as 32 got %d (%v)", val, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package codec encodes sequence values as short, URL-safe strings and
+// decodes them back into sequence values. Three encodings are provided:
+//
+//     Base62     digits and upper and lower case letters, e.g. 1000000 -> "4C92"
+//     Crockford  Crockford's base32, case-insensitive and tolerant of typos
+//     Sqids      short ids with a shuffled alphabet, padding and a blocklist
+//
+// Any Codec can be wrapped around a sequence.Incrementer such as a Sequence
+// or AtomicSequence so that it issues encoded values:
+//
+//     seq, err := sequence.New()
+//     enc := codec.NewEncoder(seq, codec.Base62)
+//     id, err := enc.Next()      // "1"
+//     idx, err := enc.Decode(id) // 1
+//
+// Encodings are reversible but not secret. Alphabets can be shuffled with a
+// key so that the ids of different applications do not look alike, but
+// values should be scrambled with a sequence.Permutation first if the order
+// in which they were issued must be hidden.
+package codec
+
+import (
+	"crypto/sha256"
+	"math/rand/v2"
+
+	"github.com/bbengfort/sequence"
+)
+
+// Codec converts sequence values to and from strings. Decode returns an error
+// for any string that Encode could not have produced, so that every valid
+// encoded value has exactly one representation.
+type Codec interface {
+	Encode(val uint64) (string, error) // Encode the value as a string
+	Decode(s string) (uint64, error)   // Decode a string produced by Encode
+}
+
+// Encoder wraps an Incrementer, encoding the values that it issues. An
+// Encoder is as safe for concurrent use as the Incrementer it wraps.
+type Encoder struct {
+	inc   sequence.Incrementer // The sequence values are drawn from
+	codec Codec                // The encoding of the values
+}
+
+// NewEncoder returns an Encoder of the values of the Incrementer.
+func NewEncoder(inc sequence.Incrementer, codec Codec) *Encoder {
+	return &Encoder{inc: inc, codec: codec}
+}
+
+// Next returns the encoded next value of the sequence.
+func (e *Encoder) Next() (string, error) {
+	val, err := e.inc.Next()
+	if err != nil {
+		return "", err
+	}
+	return e.codec.Encode(val)
+}
+
+// Current returns the encoded current value of the sequence.
+func (e *Encoder) Current() (string, error) {
+	val, err := e.inc.Current()
+	if err != nil {
+		return "", err
+	}
+	return e.codec.Encode(val)
+}
+
+// Encode a value with the codec of the encoder.
+func (e *Encoder) Encode(val uint64) (string, error) {
+	return e.codec.Encode(val)
+}
+
+// Decode a value with the codec of the encoder.
+func (e *Encoder) Decode(s string) (uint64, error) {
+	return e.codec.Decode(s)
+}
+
+// Shuffle returns the characters of the alphabet in an order determined by
+// the key, which can be passed to NewBase or NewSqids so that the encoded
+// values of different applications do not look alike. The same key always
+// produces the same order.
+func Shuffle(alphabet string, key []byte) string {
+	rng := rand.NewChaCha8(sha256.Sum256(key))
+	chars := []byte(alphabet)
+
+	// The Fisher-Yates shuffle is implemented here rather than using
+	// rand.Shuffle so that the order never changes between Go releases.
+	for i := len(chars) - 1; i > 0; i-- {
+		j := rng.Uint64() % uint64(i+1)
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
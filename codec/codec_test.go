@@ -0,0 +1,77 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bbengfort/sequence"
+)
+
+// Ensure that the codecs implement the Codec interface.
+func TestInterface(t *testing.T) {
+	var _ Codec = &Base{}
+	var _ Codec = &Sqids{}
+	var _ Codec = Crockford
+}
+
+// Test issuing encoded values from sequences.
+func TestEncoder(t *testing.T) {
+	atomic, _ := sequence.NewAtomic(100)
+	sqids, _ := NewSqids("", 8, nil)
+
+	for _, codec := range []Codec{Base62, Crockford, sqids} {
+		seq, _ := sequence.New(100)
+		for _, inc := range []sequence.Incrementer{seq, atomic} {
+			enc := NewEncoder(inc, codec)
+			if _, err := enc.Current(); err == nil {
+				t.Error("encoded the current value of an unstarted sequence")
+			}
+
+			seen := make(map[string]bool)
+			for i := uint64(1); i <= 100; i++ {
+				id, err := enc.Next()
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+
+				if seen[id] {
+					t.Fatalf("duplicate id %q", id)
+				}
+				seen[id] = true
+
+				// Sequences at their maximum value are not started.
+				if current, err := enc.Current(); i < 100 && (err != nil || current != id) {
+					t.Errorf("expected current %q got %q (%v)", id, current, err)
+				}
+
+				if val, err := enc.Decode(id); err != nil || val != i {
+					t.Errorf("expected %q to decode as %d got %d (%v)", id, i, val, err)
+				}
+			}
+
+			if _, err := enc.Next(); !errors.Is(err, sequence.ErrExhausted) {
+				t.Errorf("expected exhausted error got %v", err)
+			}
+			atomic.Restart()
+		}
+	}
+}
+
+// Test that shuffling is deterministic and keyed.
+func TestShuffle(t *testing.T) {
+	a := Shuffle(Base62.Alphabet(), []byte("secret"))
+	b := Shuffle(Base62.Alphabet(), []byte("secret"))
+	c := Shuffle(Base62.Alphabet(), []byte("another secret"))
+
+	if a != b {
+		t.Error("the same key produced different alphabets")
+	}
+
+	if a == c || a == Base62.Alphabet() {
+		t.Error("the alphabet was not shuffled by the key")
+	}
+
+	if _, err := NewBase(a); err != nil {
+		t.Errorf("shuffled alphabet is not valid: %s", err)
+	}
+}
@@ -0,0 +1,250 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultSqidsAlphabet is the alphabet of the reference Sqids implementations.
+const DefaultSqidsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Sqids encodes values as short ids following the Sqids specification
+// (https://sqids.org), so ids are compatible with the Sqids libraries of
+// other languages configured with the same alphabet, minimum length and
+// blocklist. The alphabet is shuffled so that consecutive values do not
+// produce similar ids, ids can be padded to a minimum length, and ids that
+// contain a word from the blocklist are never produced. Unlike the reference
+// implementations the blocklist is empty unless one is specified.
+type Sqids struct {
+	alphabet  []byte   // The shuffled alphabet
+	minLength int      // The minimum length of an id
+	blocklist []string // Lower case words that ids must not contain
+}
+
+// NewSqids returns a Sqids codec. The alphabet must contain at least 3 unique
+// ASCII characters; DefaultSqidsAlphabet is used if it is empty. Ids are
+// padded to minLength characters, which must be at most 255. Blocklist words
+// are matched case-insensitively; words shorter than 3 characters or that
+// contain characters not in the alphabet can never match and are ignored.
+func NewSqids(alphabet string, minLength int, blocklist []string) (*Sqids, error) {
+	if alphabet == "" {
+		alphabet = DefaultSqidsAlphabet
+	}
+
+	if len(alphabet) < 3 {
+		return nil, errors.New("alphabet must contain at least 3 characters")
+	}
+
+	var seen [128]bool
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 {
+			return nil, fmt.Errorf("alphabet contains non-ASCII character at position %d", i)
+		}
+
+		if seen[c] {
+			return nil, fmt.Errorf("alphabet contains duplicate character %q", c)
+		}
+		seen[c] = true
+	}
+
+	if minLength < 0 || minLength > 255 {
+		return nil, errors.New("minimum length must be between 0 and 255")
+	}
+
+	lower := strings.ToLower(alphabet)
+	s := &Sqids{alphabet: shuffle([]byte(alphabet)), minLength: minLength}
+	for _, word := range blocklist {
+		word = strings.ToLower(word)
+		if len(word) >= 3 && strings.Trim(word, lower) == "" {
+			s.blocklist = append(s.blocklist, word)
+		}
+	}
+	return s, nil
+}
+
+// Encode a single value.
+func (s *Sqids) Encode(val uint64) (string, error) {
+	return s.EncodeNumbers([]uint64{val})
+}
+
+// Decode an id that encodes a single value. An error is returned if the id
+// does not encode exactly one value or if it is not the id that Encode would
+// produce for that value, e.g. because it is padded differently.
+func (s *Sqids) Decode(id string) (uint64, error) {
+	vals := s.DecodeNumbers(id)
+	if len(vals) != 1 {
+		return 0, fmt.Errorf("cannot decode %q as a single value", id)
+	}
+
+	if canonical, err := s.Encode(vals[0]); err != nil || canonical != id {
+		return 0, fmt.Errorf("cannot decode %q: not a canonical id", id)
+	}
+	return vals[0], nil
+}
+
+// EncodeNumbers encodes several values as a single id. An empty id is
+// returned if no values are given. An error is returned in the unlikely event
+// that every candidate id contains a word from the blocklist.
+func (s *Sqids) EncodeNumbers(vals []uint64) (string, error) {
+	if len(vals) == 0 {
+		return "", nil
+	}
+	return s.encode(vals, 0)
+}
+
+// Encode the values, skipping increment candidate ids that were blocked.
+func (s *Sqids) encode(vals []uint64, increment int) (string, error) {
+	size := len(s.alphabet)
+	if increment > size {
+		return "", errors.New("could not generate an id that is not blocked")
+	}
+
+	offset := len(vals)
+	for i, val := range vals {
+		offset += int(s.alphabet[val%uint64(size)]) + i
+	}
+	offset = (offset%size + increment) % size
+
+	alphabet := make([]byte, 0, size)
+	alphabet = append(alphabet, s.alphabet[offset:]...)
+	alphabet = append(alphabet, s.alphabet[:offset]...)
+	prefix := alphabet[0]
+	reverse(alphabet)
+
+	id := []byte{prefix}
+	for i, val := range vals {
+		id = append(id, toID(val, alphabet[1:])...)
+		if i < len(vals)-1 {
+			id = append(id, alphabet[0])
+			alphabet = shuffle(alphabet)
+		}
+	}
+
+	if len(id) < s.minLength {
+		id = append(id, alphabet[0])
+		for len(id) < s.minLength {
+			alphabet = shuffle(alphabet)
+			id = append(id, alphabet[:min(s.minLength-len(id), size)]...)
+		}
+	}
+
+	if s.blocked(string(id)) {
+		return s.encode(vals, increment+1)
+	}
+	return string(id), nil
+}
+
+// DecodeNumbers returns the values encoded in the id, or nil if the id
+// contains characters that are not in the alphabet. Any id can be decoded,
+// so callers that require an id produced by EncodeNumbers should encode the
+// decoded values and compare them to the id.
+func (s *Sqids) DecodeNumbers(id string) []uint64 {
+	if id == "" {
+		return nil
+	}
+
+	for i := 0; i < len(id); i++ {
+		if !strings.ContainsRune(string(s.alphabet), rune(id[i])) {
+			return nil
+		}
+	}
+
+	offset := strings.IndexByte(string(s.alphabet), id[0])
+	alphabet := make([]byte, 0, len(s.alphabet))
+	alphabet = append(alphabet, s.alphabet[offset:]...)
+	alphabet = append(alphabet, s.alphabet[:offset]...)
+	reverse(alphabet)
+
+	var vals []uint64
+	for rest := id[1:]; rest != ""; {
+		chunk, tail, found := strings.Cut(rest, string(alphabet[0]))
+		if chunk == "" {
+			// The remainder of the id is padding.
+			break
+		}
+
+		val, ok := toNumber(chunk, alphabet[1:])
+		if !ok {
+			return nil
+		}
+		vals = append(vals, val)
+
+		if found {
+			alphabet = shuffle(alphabet)
+		}
+		rest = tail
+	}
+	return vals
+}
+
+// Returns true if the id contains a word from the blocklist. Short words and
+// ids must match exactly and words that contain digits must be at the start
+// or end of the id, as described by the specification.
+func (s *Sqids) blocked(id string) bool {
+	id = strings.ToLower(id)
+	for _, word := range s.blocklist {
+		switch {
+		case len(word) > len(id):
+			continue
+		case len(id) <= 3 || len(word) <= 3:
+			if id == word {
+				return true
+			}
+		case strings.ContainsAny(word, "0123456789"):
+			if strings.HasPrefix(id, word) || strings.HasSuffix(id, word) {
+				return true
+			}
+		case strings.Contains(id, word):
+			return true
+		}
+	}
+	return false
+}
+
+// The deterministic shuffle of the specification, returning a new slice.
+func shuffle(alphabet []byte) []byte {
+	chars := append([]byte(nil), alphabet...)
+	for i, j := 0, len(chars)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % len(chars)
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+	return chars
+}
+
+// Reverses the alphabet in place.
+func reverse(alphabet []byte) {
+	for i, j := 0, len(alphabet)-1; i < j; i, j = i+1, j-1 {
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+}
+
+// Encodes the value in the base of the alphabet.
+func toID(val uint64, alphabet []byte) []byte {
+	base := uint64(len(alphabet))
+
+	var buf [64]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = alphabet[val%base]
+		if val /= base; val == 0 {
+			return buf[i:]
+		}
+	}
+}
+
+// Decodes a value in the base of the alphabet, returning false on overflow.
+func toNumber(id string, alphabet []byte) (uint64, bool) {
+	base := uint64(len(alphabet))
+	var val uint64
+	for i := 0; i < len(id); i++ {
+		digit := uint64(strings.IndexByte(string(alphabet), id[i]))
+		if val > (^uint64(0)-digit)/base {
+			return 0, false
+		}
+		val = val*base + digit
+	}
+	return val, true
+}
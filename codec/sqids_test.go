@@ -0,0 +1,140 @@
+package codec
+
+import (
+	"slices"
+	"testing"
+)
+
+// Test ids produced by the reference Sqids implementations.
+func TestSqidsReference(t *testing.T) {
+	s, err := NewSqids("", 0, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if id, err := s.EncodeNumbers([]uint64{1, 2, 3}); err != nil || id != "86Rf07" {
+		t.Errorf("expected 86Rf07 got %q (%v)", id, err)
+	}
+
+	if vals := s.DecodeNumbers("86Rf07"); !slices.Equal(vals, []uint64{1, 2, 3}) {
+		t.Errorf("expected [1 2 3] got %v", vals)
+	}
+
+	for val, expected := range []string{"bM", "Uk", "gb", "Ef", "Vq", "uw", "OI", "AX", "p6", "nJ"} {
+		if id, err := s.Encode(uint64(val)); err != nil || id != expected {
+			t.Errorf("expected %d to encode as %q got %q (%v)", val, expected, id, err)
+		}
+	}
+
+	if id, _ := s.Encode(100000); id != "ArUO" {
+		t.Errorf("expected ArUO got %q", id)
+	}
+}
+
+// Test padding ids to a minimum length.
+func TestSqidsMinLength(t *testing.T) {
+	s, _ := NewSqids("", len(DefaultSqidsAlphabet), nil)
+
+	id, err := s.EncodeNumbers([]uint64{1, 2, 3})
+	if err != nil || id != "86Rf07xd4zBmiJXQG6otHEbew02c3PWsUOLZxADhCpKj7aVFv9I8RquYrNlSTM" {
+		t.Errorf("unexpected padded id %q (%v)", id, err)
+	}
+
+	if vals := s.DecodeNumbers(id); !slices.Equal(vals, []uint64{1, 2, 3}) {
+		t.Errorf("expected [1 2 3] got %v", vals)
+	}
+
+	s, _ = NewSqids("", 10, nil)
+	for _, val := range []uint64{0, 1, 62, 1000000, ^uint64(0)} {
+		id, err := s.Encode(val)
+		if err != nil || len(id) < 10 {
+			t.Errorf("expected an id of at least 10 characters got %q (%v)", id, err)
+		}
+
+		if decoded, err := s.Decode(id); err != nil || decoded != val {
+			t.Errorf("expected %q to decode to %d got %d (%v)", id, val, decoded, err)
+		}
+	}
+}
+
+// Test that blocked words are not produced.
+func TestSqidsBlocklist(t *testing.T) {
+	s, _ := NewSqids("", 0, []string{"ArUO", "no", "ab!"})
+	if len(s.blocklist) != 1 {
+		t.Errorf("expected short and invalid words to be ignored got %v", s.blocklist)
+	}
+
+	if id, _ := s.Encode(100000); id != "QyG4" {
+		t.Errorf("expected QyG4 got %q", id)
+	}
+
+	// Blocked ids can still be decoded, but are not canonical.
+	if vals := s.DecodeNumbers("ArUO"); !slices.Equal(vals, []uint64{100000}) {
+		t.Errorf("expected [100000] got %v", vals)
+	}
+
+	if _, err := s.Decode("ArUO"); err == nil {
+		t.Error("decoded a blocked id")
+	}
+
+	if val, err := s.Decode("QyG4"); err != nil || val != 100000 {
+		t.Errorf("expected 100000 got %d (%v)", val, err)
+	}
+
+	// Blocking every candidate id is an error.
+	s, _ = NewSqids("abc", 3, []string{"aaa", "aab", "aac", "aba", "abb", "abc", "aca", "acb", "acc", "baa", "bab", "bac", "bba", "bbb", "bbc", "bca", "bcb", "bcc", "caa", "cab", "cac", "cba", "cbb", "cbc", "cca", "ccb", "ccc"})
+	if _, err := s.Encode(1); err == nil {
+		t.Error("encoded an id with every candidate blocked")
+	}
+}
+
+// Test that only canonical ids that encode a single value are decoded.
+func TestSqidsDecodeErrors(t *testing.T) {
+	s, _ := NewSqids("", 0, nil)
+
+	for _, id := range []string{"", "86Rf07", "*bM", "bM-", "bMbM"} {
+		if val, err := s.Decode(id); err == nil {
+			t.Errorf("decoded bad id %q as %d", id, val)
+		}
+	}
+
+	if vals := s.DecodeNumbers("*"); vals != nil {
+		t.Errorf("decoded invalid characters as %v", vals)
+	}
+}
+
+// Test that bad configurations are rejected.
+func TestNewSqidsErrors(t *testing.T) {
+	for _, alphabet := range []string{"ab", "aab", "abcé"} {
+		if _, err := NewSqids(alphabet, 0, nil); err == nil {
+			t.Errorf("created sqids with bad alphabet %q", alphabet)
+		}
+	}
+
+	for _, length := range []int{-1, 256} {
+		if _, err := NewSqids("", length, nil); err == nil {
+			t.Errorf("created sqids with bad minimum length %d", length)
+		}
+	}
+}
+
+// Test round trips with custom alphabets.
+func TestSqidsAlphabet(t *testing.T) {
+	for _, alphabet := range []string{"abc", "0123456789", Shuffle(DefaultSqidsAlphabet, []byte("secret"))} {
+		s, err := NewSqids(alphabet, 0, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for _, val := range []uint64{0, 1, 2, 3, 99, 12345678, ^uint64(0)} {
+			id, err := s.Encode(val)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if decoded, err := s.Decode(id); err != nil || decoded != val {
+				t.Errorf("expected %q to decode to %d got %d (%v)", id, val, decoded, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled is returned, wrapped together with the context error, when a
+// context-aware sequence operation is canceled or its deadline expires. Both
+// errors.Is(err, ErrCanceled) and errors.Is(err, context.DeadlineExceeded) (or
+// context.Canceled) are true for these errors.
+var ErrCanceled = errors.New("sequence operation canceled")
+
+// ContextIncrementer is implemented by sequences whose operations respect the
+// deadlines and cancellation of a context. Implementations that may wait,
+// such as rate-limited or remote sequences, block until a value is available
+// or the context is done. Implementations that never wait check the context
+// before modifying their state, so a canceled context never consumes values.
+type ContextIncrementer interface {
+	NextContext(ctx context.Context) (uint64, error)                 // Get the next value unless the context is done
+	ReserveContext(ctx context.Context, n uint64) ([]uint64, error) // Get the next n values unless the context is done
+}
+
+// WrapContextError wraps a context error so that it matches both ErrCanceled
+// and the original context error. Implementations of ContextIncrementer
+// should use this function to ensure that errors are handled consistently.
+// If err is nil, nil is returned.
+func WrapContextError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrCanceled, err)
+}
+
+// NextContext returns the next value of any Incrementer, respecting the
+// deadline and cancellation of the context. If the Incrementer implements
+// ContextIncrementer its NextContext method is used, otherwise the context
+// is checked before calling Next.
+func NextContext(ctx context.Context, inc Incrementer) (uint64, error) {
+	if cinc, ok := inc.(ContextIncrementer); ok {
+		return cinc.NextContext(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, WrapContextError(err)
+	}
+	return inc.Next()
+}
+
+// ReserveContext returns the next n values of any Incrementer, respecting the
+// deadline and cancellation of the context. If the Incrementer implements
+// ContextIncrementer its ReserveContext method is used. Otherwise Next is
+// called n times, checking the context before each call; in this case the
+// reservation is not atomic and the values consumed before an error are lost.
+func ReserveContext(ctx context.Context, inc Incrementer, n uint64) ([]uint64, error) {
+	if cinc, ok := inc.(ContextIncrementer); ok {
+		return cinc.ReserveContext(ctx, n)
+	}
+
+	vals := make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, WrapContextError(err)
+		}
+
+		val, err := inc.Next()
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// Expands a reserved block of values into a slice.
+func reserved(first, last, step uint64) []uint64 {
+	vals := make([]uint64, 0, (last-first)/step+1)
+	for val := first; ; val += step {
+		vals = append(vals, val)
+		if val == last {
+			return vals
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Ensure that both sequence types implement the ContextIncrementer interface.
+func TestContextInterface(t *testing.T) {
+	var _ ContextIncrementer = &Sequence{}
+	var _ ContextIncrementer = &AtomicSequence{}
+}
+
+// Test that context errors are wrapped consistently.
+func TestWrapContextError(t *testing.T) {
+	if WrapContextError(nil) != nil {
+		t.Error("wrapped a nil error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := WrapContextError(ctx.Err())
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wrapped error %q does not match both errors", err)
+	}
+}
+
+// Test that a canceled context does not consume values.
+func TestNextContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	seqs := []Incrementer{&Sequence{}, &AtomicSequence{}}
+	for _, seq := range seqs {
+		seq.Init()
+
+		if _, err := NextContext(ctx, seq); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected canceled error got %v", err)
+		}
+
+		if _, err := ReserveContext(ctx, seq, 10); !errors.Is(err, ErrCanceled) {
+			t.Errorf("expected canceled error got %v", err)
+		}
+
+		if seq.IsStarted() {
+			t.Error("canceled context consumed values from the sequence")
+		}
+
+		if idx, err := NextContext(context.Background(), seq); err != nil || idx != 1 {
+			t.Errorf("expected 1 got %d (%v)", idx, err)
+		}
+	}
+}
+
+// Test reserving blocks of values from a sequence.
+func TestReserveContext(t *testing.T) {
+	ctx := context.Background()
+	seqs := []ContextIncrementer{&Sequence{}, &AtomicSequence{}}
+
+	for _, seq := range seqs {
+		seq.(Incrementer).Init(2, 20, 2)
+
+		vals, err := seq.ReserveContext(ctx, 3)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if len(vals) != 3 || vals[0] != 2 || vals[1] != 4 || vals[2] != 6 {
+			t.Errorf("unexpected reservation %v", vals)
+		}
+
+		if idx, _ := seq.NextContext(ctx); idx != 8 {
+			t.Errorf("expected 8 after reservation got %d", idx)
+		}
+
+		// Reservations are all or nothing.
+		if _, err := seq.ReserveContext(ctx, 7); err == nil {
+			t.Error("reserved more values than remain in the sequence")
+		}
+
+		if _, err := seq.ReserveContext(ctx, 0); err == nil {
+			t.Error("reserved zero values")
+		}
+
+		vals, err = seq.ReserveContext(ctx, 6)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if len(vals) != 6 || vals[0] != 10 || vals[5] != 20 {
+			t.Errorf("unexpected reservation %v", vals)
+		}
+
+		if _, err := seq.NextContext(ctx); err == nil {
+			t.Error("sequence did not error after its maximum bound")
+		}
+	}
+
+	if _, err := new(Sequence).ReserveContext(ctx, 1); err == nil {
+		t.Error("reserved values from an uninitialized sequence")
+	}
+}
+
+// Test that concurrent reservations never overlap.
+func TestReserveContextAtomic(t *testing.T) {
+	seq, err := NewAtomic()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				vals, err := seq.ReserveContext(context.Background(), 10)
+				if err != nil {
+					t.Error(err.Error())
+					return
+				}
+
+				mu.Lock()
+				for _, val := range vals {
+					if seen[val] {
+						t.Errorf("duplicate value %d", val)
+					}
+					seen[val] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	if len(seen) != 8000 {
+		t.Errorf("expected 8000 values got %d", len(seen))
+	}
+}
+
+// A sequence that only implements the Incrementer interface.
+type plainIncrementer struct {
+	Incrementer
+}
+
+// Test that the package functions work on incrementers without context methods.
+func TestReserveContextFallback(t *testing.T) {
+	seq := &plainIncrementer{new(Sequence)}
+	seq.Init(5)
+
+	if _, ok := interface{}(seq).(ContextIncrementer); ok {
+		t.Fatal("test incrementer should not implement ContextIncrementer")
+	}
+
+	vals, err := ReserveContext(context.Background(), seq, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(vals) != 3 || vals[2] != 3 {
+		t.Errorf("unexpected reservation %v", vals)
+	}
+
+	if _, err := ReserveContext(context.Background(), seq, 3); err == nil {
+		t.Error("reserved more values than remain in the sequence")
+	}
+}
@@ -27,7 +27,8 @@
 // bound, which by default is the maximal uint64 value, such that incrementing
 // will not start to repeat values. If the increment is negative, then the
 // sequence will return an error if it reaches a minimum bound, which by
-// default is 0 since the Sequence will always return positive values.
+// default is 0 since the Sequence will always return positive values. Both
+// errors wrap ErrExhausted.
 //
 // The Sequence object provides several helper methods to interact with it
 // during long running processes, including Current(), IsStarted(), and
@@ -0,0 +1,328 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultForecastWindow is the period over which the issuance rate of a
+// Monitor is averaged if no window is specified.
+const DefaultForecastWindow = time.Minute
+
+// Forecast describes the remaining capacity of a sequence and, based on the
+// recent rate at which values have been issued, when it will be exhausted.
+type Forecast struct {
+	Capacity  uint64        // The total number of values in the range of the sequence
+	Remaining uint64        // The number of values Next can still return
+	Used      float64       // The fraction of the range that has been used, from 0 to 1
+	Rate      float64       // The average number of values issued per second
+	Exhausts  bool          // True if the sequence is projected to be exhausted
+	ExhaustIn time.Duration // The projected time until exhaustion, if Exhausts is true
+}
+
+// ThresholdFunc is called when the usage of a monitored sequence crosses a
+// threshold registered with Monitor.OnThreshold.
+type ThresholdFunc func(threshold float64, forecast Forecast)
+
+// A registered threshold and whether it has fired.
+type threshold struct {
+	usage float64
+	fn    ThresholdFunc
+	fired bool
+}
+
+// Monitor wraps a bounded Incrementer, tracking the rate at which values are
+// issued in order to forecast when the sequence will be exhausted. Callbacks
+// can be registered to fire when usage crosses thresholds such as 80% or 95%
+// so that running out of a bounded sequence is not discovered only when Next
+// returns an error.
+//
+// The issuance rate is an exponentially weighted moving average over the
+// forecast window, measured by the change in remaining values; values that are
+// skipped by Update count towards the rate since they also use up the range.
+// Calls are passed to the wrapped Incrementer without holding the lock of the
+// monitor, so a call that blocks, e.g. on a rate limiter, does not stall
+// Forecast. A Monitor is therefore safe for concurrent use only if the wrapped
+// Incrementer is, such as an AtomicSequence or a sequence wrapped with
+// Synchronized; it should not be modified directly once wrapped.
+type Monitor struct {
+	mu         sync.Mutex
+	inc        Incrementer   // The monitored sequence
+	bounded    Bounded       // The monitored sequence's range
+	window     time.Duration // The period the rate is averaged over
+	clock      Clock         // The source of time for measuring the rate
+	rate       float64       // The average number of values issued per second
+	pending    uint64        // Values issued since the rate was last updated
+	last       time.Time     // The last time the rate was updated
+	remaining  uint64        // The remaining values when last observed
+	thresholds []*threshold  // Registered thresholds ordered by usage
+}
+
+// NewMonitor wraps an initialized Incrementer that implements Bounded, such
+// as a Sequence or AtomicSequence, averaging the issuance rate over the
+// window (DefaultForecastWindow if zero).
+func NewMonitor(inc Incrementer, window time.Duration) (*Monitor, error) {
+	bounded, ok := inc.(Bounded)
+	if !ok {
+		return nil, errors.New("cannot monitor a sequence that does not implement Bounded")
+	}
+
+	if window <= 0 {
+		window = DefaultForecastWindow
+	}
+
+	clock := systemClock{}
+	return &Monitor{
+		inc:       inc,
+		bounded:   bounded,
+		window:    window,
+		clock:     clock,
+		last:      clock.Now(),
+		remaining: bounded.Remaining(),
+	}, nil
+}
+
+// SetClock replaces the source of time of the monitor, resetting the rate.
+// This is primarily used to inject a clock for testing.
+func (m *Monitor) SetClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+	m.last = clock.Now()
+	m.rate = 0
+	m.pending = 0
+}
+
+// OnThreshold registers a callback that fires once when the fraction of the
+// range that has been used crosses usage, which must be in (0, 1]. If the
+// threshold has already been crossed the callback fires immediately. The
+// threshold is re-armed if usage later falls below it, e.g. after Restart.
+// Callbacks are called synchronously after the operation that crossed the
+// threshold, so they should not block.
+func (m *Monitor) OnThreshold(usage float64, fn ThresholdFunc) error {
+	if usage <= 0 || usage > 1 || math.IsNaN(usage) {
+		return fmt.Errorf("threshold %f must be greater than 0 and at most 1", usage)
+	}
+
+	if fn == nil {
+		return errors.New("threshold callback cannot be nil")
+	}
+
+	m.mu.Lock()
+	m.thresholds = append(m.thresholds, &threshold{usage: usage, fn: fn})
+	sort.SliceStable(m.thresholds, func(i, j int) bool {
+		return m.thresholds[i].usage < m.thresholds[j].usage
+	})
+	m.mu.Unlock()
+
+	m.check()
+	return nil
+}
+
+// Forecast returns the remaining capacity of the sequence and the projected
+// time until it is exhausted at the current issuance rate.
+func (m *Monitor) Forecast() Forecast {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.forecast()
+}
+
+// Returns the forecast, updating the rate. Must be called with the lock.
+func (m *Monitor) forecast() Forecast {
+	m.observe()
+
+	minvalue, maxvalue, increment := m.bounded.Bounds()
+	f := Forecast{Remaining: m.remaining, Rate: m.rate}
+	if increment == 0 {
+		// The sequence has not been initialized.
+		return f
+	}
+
+	f.Capacity = (maxvalue-minvalue)/increment + 1
+	f.Used = 1 - float64(f.Remaining)/float64(f.Capacity)
+
+	if f.Remaining == 0 {
+		f.Exhausts = true
+	} else if f.Rate > 0 {
+		if secs := float64(f.Remaining) / f.Rate; secs < float64(math.MaxInt64)/float64(time.Second) {
+			f.Exhausts = true
+			f.ExhaustIn = time.Duration(secs * float64(time.Second))
+		}
+	}
+	return f
+}
+
+// Observe the change in remaining values, updating the issuance rate if time
+// has passed since the last observation. Must be called with the lock.
+func (m *Monitor) observe() {
+	remaining := m.bounded.Remaining()
+	if remaining < m.remaining {
+		m.pending += m.remaining - remaining
+	}
+	m.remaining = remaining
+
+	now := m.clock.Now()
+	elapsed := now.Sub(m.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	instant := float64(m.pending) / elapsed.Seconds()
+	alpha := 1 - math.Exp(-float64(elapsed)/float64(m.window))
+	m.rate += alpha * (instant - m.rate)
+	m.pending = 0
+	m.last = now
+}
+
+// Fire any thresholds that have been crossed and re-arm any thresholds that
+// are no longer crossed. Callbacks are called without the lock held.
+func (m *Monitor) check() {
+	m.mu.Lock()
+	if len(m.thresholds) == 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	f := m.forecast()
+	var fire []*threshold
+	for _, t := range m.thresholds {
+		switch {
+		case !t.fired && f.Used >= t.usage:
+			t.fired = true
+			fire = append(fire, t)
+		case t.fired && f.Used < t.usage:
+			t.fired = false
+		}
+	}
+	m.mu.Unlock()
+
+	for _, t := range fire {
+		t.fn(t.usage, f)
+	}
+}
+
+//===========================================================================
+// Monitored Sequence Methods
+//===========================================================================
+
+// Init initializes the monitored sequence.
+func (m *Monitor) Init(params ...uint64) error {
+	err := m.inc.Init(params...)
+
+	m.mu.Lock()
+	m.remaining = m.bounded.Remaining()
+	m.mu.Unlock()
+
+	m.check()
+	return err
+}
+
+// Next returns the next value of the monitored sequence, firing any
+// thresholds that are crossed.
+func (m *Monitor) Next() (uint64, error) {
+	val, err := m.inc.Next()
+
+	m.mu.Lock()
+	m.observe()
+	m.mu.Unlock()
+
+	m.check()
+	return val, err
+}
+
+// NextContext returns the next value of the monitored sequence as described
+// by the package NextContext function, firing any thresholds that are crossed.
+func (m *Monitor) NextContext(ctx context.Context) (uint64, error) {
+	val, err := NextContext(ctx, m.inc)
+
+	m.mu.Lock()
+	m.observe()
+	m.mu.Unlock()
+
+	m.check()
+	return val, err
+}
+
+// ReserveContext reserves n values of the monitored sequence as described by
+// the package ReserveContext function, firing any thresholds that are crossed.
+func (m *Monitor) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	vals, err := ReserveContext(ctx, m.inc, n)
+
+	m.mu.Lock()
+	m.observe()
+	m.mu.Unlock()
+
+	m.check()
+	return vals, err
+}
+
+// Restart the monitored sequence, re-arming thresholds.
+func (m *Monitor) Restart() error {
+	err := m.inc.Restart()
+
+	m.mu.Lock()
+	m.observe()
+	m.mu.Unlock()
+
+	m.check()
+	return err
+}
+
+// Update the monitored sequence, firing any thresholds that are crossed.
+func (m *Monitor) Update(val uint64) error {
+	err := m.inc.Update(val)
+
+	m.mu.Lock()
+	m.observe()
+	m.mu.Unlock()
+
+	m.check()
+	return err
+}
+
+// Current returns the current value of the monitored sequence.
+func (m *Monitor) Current() (uint64, error) {
+	return m.inc.Current()
+}
+
+// IsStarted returns the state of the monitored sequence.
+func (m *Monitor) IsStarted() bool {
+	return m.inc.IsStarted()
+}
+
+// Bounds returns the range of the monitored sequence.
+func (m *Monitor) Bounds() (minvalue, maxvalue, increment uint64) {
+	return m.bounded.Bounds()
+}
+
+// Remaining returns the number of values the monitored sequence can issue.
+func (m *Monitor) Remaining() uint64 {
+	return m.bounded.Remaining()
+}
+
+// String returns a human readable representation of the monitored sequence.
+func (m *Monitor) String() string {
+	return m.inc.String()
+}
+
+// Dump the monitored sequence. The issuance rate is not dumped.
+func (m *Monitor) Dump() ([]byte, error) {
+	return m.inc.Dump()
+}
+
+// Load the monitored sequence, firing any thresholds already crossed.
+func (m *Monitor) Load(data []byte) error {
+	err := m.inc.Load(data)
+
+	m.mu.Lock()
+	m.remaining = m.bounded.Remaining()
+	m.mu.Unlock()
+
+	m.check()
+	return err
+}
@@ -0,0 +1,205 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Ensure that the monitor implements the sequence interfaces.
+func TestMonitorInterface(t *testing.T) {
+	var _ Incrementer = &Monitor{}
+	var _ ContextIncrementer = &Monitor{}
+	var _ Bounded = &Monitor{}
+}
+
+// Test that only bounded sequences can be monitored.
+func TestNewMonitorUnbounded(t *testing.T) {
+	if _, err := NewMonitor(&plainIncrementer{new(Sequence)}, 0); err == nil {
+		t.Error("monitored a sequence without bounds")
+	}
+}
+
+// Test the remaining capacity and projected time to exhaustion.
+func TestMonitorForecast(t *testing.T) {
+	seq, _ := New(1000)
+	clock := newMockClock()
+
+	mon, err := NewMonitor(seq, time.Second)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mon.SetClock(clock)
+
+	f := mon.Forecast()
+	if f.Capacity != 1000 || f.Remaining != 1000 || f.Used != 0 || f.Exhausts {
+		t.Errorf("unexpected initial forecast %+v", f)
+	}
+
+	// Issue 10 values per second for long enough for the average to settle.
+	for i := 0; i < 30; i++ {
+		for j := 0; j < 10; j++ {
+			if _, err := mon.Next(); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+		clock.Advance(time.Second)
+		f = mon.Forecast()
+	}
+
+	if f.Remaining != 700 || math.Abs(f.Used-0.3) > 1e-9 {
+		t.Errorf("unexpected usage %+v", f)
+	}
+
+	if math.Abs(f.Rate-10) > 0.01 {
+		t.Errorf("expected a rate of 10 values per second got %f", f.Rate)
+	}
+
+	if !f.Exhausts || f.ExhaustIn < 69*time.Second || f.ExhaustIn > 71*time.Second {
+		t.Errorf("expected exhaustion in 70s got %s", f.ExhaustIn)
+	}
+
+	// The rate decays when no values are issued.
+	clock.Advance(time.Minute)
+	if f = mon.Forecast(); f.Rate > 0.01 {
+		t.Errorf("rate did not decay: %f", f.Rate)
+	}
+}
+
+// Test that thresholds fire once when crossed and are re-armed by Restart.
+func TestMonitorThresholds(t *testing.T) {
+	seq, _ := New(100)
+	mon, _ := NewMonitor(seq, 0)
+	mon.SetClock(newMockClock())
+
+	fired := make(map[float64]int)
+	record := func(threshold float64, f Forecast) {
+		if f.Used < threshold {
+			t.Errorf("threshold %f fired at usage %f", threshold, f.Used)
+		}
+		fired[threshold]++
+	}
+
+	if err := mon.OnThreshold(0.95, record); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := mon.OnThreshold(0.8, record); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, bad := range []float64{0, -1, 1.5, math.NaN()} {
+		if err := mon.OnThreshold(bad, record); err == nil {
+			t.Errorf("registered bad threshold %f", bad)
+		}
+	}
+
+	for i := 0; i < 79; i++ {
+		mon.Next()
+	}
+
+	if len(fired) != 0 {
+		t.Fatalf("thresholds fired early: %v", fired)
+	}
+
+	mon.Next()
+	if fired[0.8] != 1 || fired[0.95] != 0 {
+		t.Fatalf("expected only the 80%% threshold to fire: %v", fired)
+	}
+
+	// Updates that skip values also cross thresholds.
+	mon.Update(99)
+	for i := 0; i < 5; i++ {
+		mon.Next()
+	}
+
+	if fired[0.8] != 1 || fired[0.95] != 1 {
+		t.Fatalf("expected each threshold to fire once: %v", fired)
+	}
+
+	// Restarting the sequence re-arms the thresholds.
+	mon.Restart()
+	for i := 0; i < 80; i++ {
+		mon.Next()
+	}
+
+	if fired[0.8] != 2 || fired[0.95] != 1 {
+		t.Errorf("threshold was not re-armed after restart: %v", fired)
+	}
+
+	// Thresholds that have already been crossed fire on registration.
+	mon.OnThreshold(0.5, record)
+	if fired[0.5] != 1 {
+		t.Errorf("crossed threshold did not fire on registration: %v", fired)
+	}
+}
+
+// Test that threshold callbacks can use the monitor without deadlocking.
+func TestMonitorThresholdReentrant(t *testing.T) {
+	seq, _ := New(10)
+	mon, _ := NewMonitor(seq, 0)
+
+	var forecast Forecast
+	mon.OnThreshold(1, func(threshold float64, f Forecast) {
+		forecast = mon.Forecast()
+	})
+
+	for i := 0; i < 10; i++ {
+		mon.Next()
+	}
+
+	if !forecast.Exhausts || forecast.Remaining != 0 {
+		t.Errorf("unexpected forecast at exhaustion %+v", forecast)
+	}
+}
+
+// A bounded Incrementer whose NextContext blocks until the context is done.
+type blockingSequence struct {
+	*AtomicSequence
+	waiting chan struct{}
+}
+
+func (s *blockingSequence) NextContext(ctx context.Context) (uint64, error) {
+	close(s.waiting)
+	<-ctx.Done()
+	return 0, WrapContextError(ctx.Err())
+}
+
+func (s *blockingSequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	_, err := s.NextContext(ctx)
+	return nil, err
+}
+
+// Test that a forecast does not wait for a call that is blocked.
+func TestMonitorForecastBlocked(t *testing.T) {
+	seq, _ := NewAtomic(10)
+	inc := &blockingSequence{AtomicSequence: seq, waiting: make(chan struct{})}
+	mon, _ := NewMonitor(inc, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := mon.NextContext(ctx)
+		done <- err
+	}()
+	<-inc.waiting
+
+	forecast := make(chan Forecast)
+	go func() { forecast <- mon.Forecast() }()
+
+	select {
+	case f := <-forecast:
+		if f.Remaining != 10 {
+			t.Errorf("expected 10 remaining values, got %d", f.Remaining)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("forecast waited for a blocked call")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected canceled error got %v", err)
+	}
+}
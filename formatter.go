@@ -0,0 +1,279 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter converts sequence values into formatted identifiers such as
+// invoice or ticket numbers and parses them back into sequence values. The
+// format is described by a template of literal text and the placeholders:
+//
+//     {SEQ}    the sequence value, zero padded to the width of the maximum value
+//     {SEQ:n}  the sequence value, zero padded to n digits
+//     {YYYY}   the four digit year
+//     {YY}     the two digit year
+//     {MM}     the two digit month
+//     {DD}     the two digit day of the month
+//
+// Literal braces are written as {{ and }}. Every template must contain
+// exactly one sequence placeholder. For example:
+//
+//     f, err := sequence.NewFormatter("INV-{YYYY}-{SEQ}", 999999)
+//     id := f.Format(123) // INV-2026-000123
+//     val, err := f.Parse(id) // 123
+//
+// The date parts are taken from the time the value is formatted. When an
+// identifier is parsed, the date parts are checked to be digits of the
+// correct width but are otherwise ignored. A Formatter is safe for concurrent
+// use once it has been created.
+type Formatter struct {
+	template string       // The template the formatter was created with
+	parts    []formatPart // The parsed template
+	clock    Clock        // The source of time for the date parts
+}
+
+// The kinds of parts a template is made of.
+type partKind uint8
+
+const (
+	partLiteral partKind = iota
+	partSequence
+	partYear
+	partShortYear
+	partMonth
+	partDay
+)
+
+// A literal or placeholder of a parsed template; width is the minimum number
+// of digits of placeholders.
+type formatPart struct {
+	kind    partKind
+	literal string
+	width   int
+}
+
+// Date placeholders and their widths.
+var dateParts = map[string]formatPart{
+	"YYYY": {kind: partYear, width: 4},
+	"YY":   {kind: partShortYear, width: 2},
+	"MM":   {kind: partMonth, width: 2},
+	"DD":   {kind: partDay, width: 2},
+}
+
+// NewFormatter parses the template, returning an error if it is malformed.
+// Sequence placeholders without an explicit width are zero padded to the
+// number of digits in maxvalue, which is usually the maximum bound of the
+// sequence being formatted; if maxvalue is zero they are not padded.
+func NewFormatter(template string, maxvalue uint64) (*Formatter, error) {
+	f := &Formatter{template: template, clock: systemClock{}}
+
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			f.parts = append(f.parts, formatPart{kind: partLiteral, literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	sequences := 0
+	for i := 0; i < len(template); i++ {
+		switch c := template[i]; {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("unmatched } at position %d of template", i)
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed placeholder at position %d of template", i)
+			}
+
+			part, err := parsePlaceholder(template[i+1:i+end], maxvalue)
+			if err != nil {
+				return nil, err
+			}
+
+			if part.kind == partSequence {
+				sequences++
+			}
+
+			flush()
+			f.parts = append(f.parts, part)
+			i += end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flush()
+
+	if sequences != 1 {
+		return nil, errors.New("template must contain exactly one sequence placeholder")
+	}
+	return f, nil
+}
+
+// Parse a placeholder without its braces.
+func parsePlaceholder(name string, maxvalue uint64) (formatPart, error) {
+	if part, ok := dateParts[name]; ok {
+		return part, nil
+	}
+
+	if name == "SEQ" {
+		part := formatPart{kind: partSequence}
+		if maxvalue > 0 {
+			part.width = len(strconv.FormatUint(maxvalue, 10))
+		}
+		return part, nil
+	}
+
+	if strings.HasPrefix(name, "SEQ:") {
+		width, err := strconv.Atoi(name[4:])
+		if err != nil || width < 1 || width > 20 {
+			return formatPart{}, fmt.Errorf("invalid width in placeholder {%s}", name)
+		}
+		return formatPart{kind: partSequence, width: width}, nil
+	}
+
+	return formatPart{}, fmt.Errorf("unknown placeholder {%s}", name)
+}
+
+// SetClock replaces the source of time used for the date parts. This is
+// primarily used to inject a clock for testing.
+func (f *Formatter) SetClock(clock Clock) {
+	f.clock = clock
+}
+
+// Template returns the template the formatter was created with.
+func (f *Formatter) Template() string {
+	return f.template
+}
+
+// Next returns the next value of the Incrementer as a formatted identifier.
+func (f *Formatter) Next(inc Incrementer) (string, error) {
+	val, err := inc.Next()
+	if err != nil {
+		return "", err
+	}
+	return f.Format(val), nil
+}
+
+// Format the value as an identifier dated with the current time.
+func (f *Formatter) Format(val uint64) string {
+	return f.FormatTime(val, f.clock.Now())
+}
+
+// FormatTime formats the value as an identifier dated with the time t.
+func (f *Formatter) FormatTime(val uint64, t time.Time) string {
+	var sb strings.Builder
+	for _, part := range f.parts {
+		switch part.kind {
+		case partLiteral:
+			sb.WriteString(part.literal)
+		case partSequence:
+			sb.WriteString(pad(val, part.width))
+		case partYear:
+			sb.WriteString(pad(uint64(t.Year()), part.width))
+		case partShortYear:
+			sb.WriteString(pad(uint64(t.Year()%100), part.width))
+		case partMonth:
+			sb.WriteString(pad(uint64(t.Month()), part.width))
+		case partDay:
+			sb.WriteString(pad(uint64(t.Day()), part.width))
+		}
+	}
+	return sb.String()
+}
+
+// Parse an identifier produced by the formatter, returning the sequence
+// value. An error is returned if the identifier does not match the template
+// or if the sequence value is not formatted as the formatter would format it,
+// e.g. if it has extra leading zeros, so that parsing and formatting always
+// round-trip.
+func (f *Formatter) Parse(id string) (uint64, error) {
+	var val uint64
+	rest := id
+
+	for i, part := range f.parts {
+		switch part.kind {
+		case partLiteral:
+			if !strings.HasPrefix(rest, part.literal) {
+				return 0, fmt.Errorf("identifier %q does not match template %q", id, f.template)
+			}
+			rest = rest[len(part.literal):]
+
+		case partSequence:
+			// The sequence value is the longest run of digits, leaving enough
+			// digits for the date parts and literal digits that follow it.
+			n := digits(rest) - f.trailingDigits(i+1)
+			if n < 1 || n < part.width {
+				return 0, fmt.Errorf("identifier %q does not match template %q", id, f.template)
+			}
+
+			var err error
+			if val, err = strconv.ParseUint(rest[:n], 10, 64); err != nil {
+				return 0, fmt.Errorf("could not parse sequence value of identifier %q: %w", id, err)
+			}
+
+			if pad(val, part.width) != rest[:n] {
+				return 0, fmt.Errorf("sequence value of identifier %q is not correctly padded", id)
+			}
+			rest = rest[n:]
+
+		default:
+			if digits(rest) < part.width {
+				return 0, fmt.Errorf("identifier %q does not match template %q", id, f.template)
+			}
+			rest = rest[part.width:]
+		}
+	}
+
+	if rest != "" {
+		return 0, fmt.Errorf("identifier %q does not match template %q", id, f.template)
+	}
+	return val, nil
+}
+
+// Returns the number of digits required by the parts that start at the given
+// index before the first character of a literal that is not a digit.
+func (f *Formatter) trailingDigits(start int) (n int) {
+	for _, part := range f.parts[start:] {
+		if part.kind != partLiteral {
+			n += part.width
+			continue
+		}
+
+		lead := digits(part.literal)
+		n += lead
+		if lead < len(part.literal) {
+			break
+		}
+	}
+	return n
+}
+
+// Zero pads the decimal representation of the value to the given width.
+func pad(val uint64, width int) string {
+	s := strconv.FormatUint(val, 10)
+	if len(s) >= width {
+		return s
+	}
+	return strings.Repeat("0", width-len(s)) + s
+}
+
+// Returns the number of leading ASCII digits in s.
+func digits(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return i
+		}
+	}
+	return len(s)
+}
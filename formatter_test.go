@@ -0,0 +1,99 @@
+package sequence
+
+import (
+	"testing"
+	"time"
+)
+
+// Test formatting and parsing identifiers with a variety of templates.
+func TestFormatter(t *testing.T) {
+	date := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		maxvalue uint64
+		val      uint64
+		id       string
+	}{
+		{"INV-{YYYY}-{SEQ}", 999999, 123, "INV-2026-000123"},
+		{"{SEQ}", 0, 42, "42"},
+		{"{SEQ}", MaximumBound, 1, "00000000000000000001"},
+		{"T{SEQ:4}", 99, 7, "T0007"},
+		{"T{SEQ:2}", 0, 12345, "T12345"},
+		{"{YY}{MM}{DD}-{SEQ:3}-X", 0, 9, "260307-009-X"},
+		{"{SEQ}{YY}{MM}", 1000, 12, "00122603"},
+		{"{{{SEQ}}}", 0, 5, "{5}"},
+		{"A{SEQ}0B", 999999, 12, "A0000120B"},
+		{"{SEQ}42", 0, 7, "742"},
+		{"{SEQ}1{YY}2-", 99, 3, "031262-"},
+	}
+
+	for _, tc := range tests {
+		f, err := NewFormatter(tc.template, tc.maxvalue)
+		if err != nil {
+			t.Errorf("could not create formatter for %q: %s", tc.template, err)
+			continue
+		}
+
+		if id := f.FormatTime(tc.val, date); id != tc.id {
+			t.Errorf("expected %q to format %d as %q got %q", tc.template, tc.val, tc.id, id)
+		}
+
+		if val, err := f.Parse(tc.id); err != nil || val != tc.val {
+			t.Errorf("expected %q to parse %q as %d got %d (%v)", tc.template, tc.id, tc.val, val, err)
+		}
+	}
+}
+
+// Test that malformed templates are rejected.
+func TestFormatterBadTemplate(t *testing.T) {
+	for _, template := range []string{
+		"", "INV-", "{SEQ}-{SEQ}", "{SEQ", "{SEQ}}", "{SEQ:0}", "{SEQ:x}", "{SEQ:21}", "{WEEK}-{SEQ}",
+	} {
+		if _, err := NewFormatter(template, 0); err == nil {
+			t.Errorf("created a formatter from bad template %q", template)
+		}
+	}
+}
+
+// Test that identifiers that were not produced by the formatter are rejected.
+func TestFormatterParseErrors(t *testing.T) {
+	f, _ := NewFormatter("INV-{YYYY}-{SEQ}", 9999)
+
+	for _, id := range []string{
+		"", "INV-2026-", "INV-2026-123", "INV-2026-00123", "INV-26-0123", "ORD-2026-0123",
+		"INV-2026-0123X", "INV-2026-99999999999999999999",
+	} {
+		if val, err := f.Parse(id); err == nil {
+			t.Errorf("parsed bad identifier %q as %d", id, val)
+		}
+	}
+
+	// Values wider than the padding are allowed if they have no leading zeros.
+	if val, err := f.Parse("INV-2026-12345"); err != nil || val != 12345 {
+		t.Errorf("could not parse wide value: %d (%v)", val, err)
+	}
+}
+
+// Test formatting the next value of a sequence with the clock.
+func TestFormatterNext(t *testing.T) {
+	seq, _ := New(1000)
+	_, maxvalue, _ := seq.Bounds()
+
+	f, err := NewFormatter("TKT-{YY}{MM}-{SEQ}", maxvalue)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f.SetClock(newMockClock())
+
+	for _, expected := range []string{"TKT-2601-0001", "TKT-2601-0002"} {
+		if id, err := f.Next(seq); err != nil || id != expected {
+			t.Errorf("expected %q got %q (%v)", expected, id, err)
+		}
+	}
+
+	seq.Update(1000)
+	if _, err := f.Next(seq); err == nil {
+		t.Error("formatted a value from an exhausted sequence")
+	}
+}
@@ -0,0 +1,56 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+)
+
+// Generate starts a goroutine that sends the values of any Incrementer on the
+// returned channel until the sequence is exhausted or the context is done,
+// closing the channel when it stops. If the Incrementer returns any other
+// error, it is sent on the error channel before the values channel is closed;
+// the error channel is closed once the values channel has been closed and
+// never receives exhaustion or context errors:
+//
+//     vals, errs := sequence.Generate(ctx, seq)
+//     for idx := range vals {
+//         fmt.Println(idx)
+//     }
+//
+//     if err := <-errs; err != nil {
+//         return err
+//     }
+//
+// Values are requested with NextContext, so Incrementers that wait for values
+// such as a blocking RateLimitedSequence are canceled by the context. Because
+// the next value is requested before it can be received, one value may be
+// consumed from the sequence but not received if the context is canceled.
+// The Incrementer should not be used by other goroutines while generating
+// unless it is safe for concurrent use.
+func Generate(ctx context.Context, inc Incrementer) (<-chan uint64, <-chan error) {
+	vals := make(chan uint64)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(vals)
+
+		for {
+			val, err := NextContext(ctx, inc)
+			if err != nil {
+				if !errors.Is(err, ErrExhausted) && !errors.Is(err, ErrCanceled) {
+					errs <- err
+				}
+				return
+			}
+
+			select {
+			case vals <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return vals, errs
+}
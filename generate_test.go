@@ -0,0 +1,72 @@
+package sequence
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Test generating all values of a bounded sequence.
+func TestGenerate(t *testing.T) {
+	seq, _ := New(100)
+	vals, errs := Generate(context.Background(), seq)
+
+	var expected uint64 = 1
+	for val := range vals {
+		if val != expected {
+			t.Fatalf("expected %d got %d", expected, val)
+		}
+		expected++
+	}
+
+	if expected != 101 {
+		t.Errorf("generated %d values", expected-1)
+	}
+
+	if err := <-errs; err != nil {
+		t.Errorf("exhaustion was reported as an error: %v", err)
+	}
+}
+
+// Test that canceling the context stops the generator.
+func TestGenerateCanceled(t *testing.T) {
+	seq, _ := NewAtomic()
+	ctx, cancel := context.WithCancel(context.Background())
+	vals, errs := Generate(ctx, seq)
+
+	for i := 0; i < 10; i++ {
+		<-vals
+	}
+	cancel()
+
+	// The error channel is closed after the generator stops.
+	if err := <-errs; err != nil {
+		t.Errorf("cancellation was reported as an error: %v", err)
+	}
+
+	if idx, _ := seq.Current(); idx < 10 || idx > 11 {
+		t.Errorf("generator consumed too many values: %d", idx)
+	}
+}
+
+// Test that errors other than exhaustion are reported.
+func TestGenerateError(t *testing.T) {
+	seq, _ := New()
+	rl, _ := NewRateLimited(seq, 2, time.Minute)
+	rl.SetClock(newMockClock())
+
+	vals, errs := Generate(context.Background(), &plainIncrementer{rl})
+
+	count := 0
+	for range vals {
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 values got %d", count)
+	}
+
+	if err := <-errs; err != ErrRateLimited {
+		t.Errorf("expected rate limit error got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package generic
+
+import (
+	"sync"
+
+	"github.com/bbengfort/sequence"
+)
+
+// NewAtomic creates an AtomicSequence over the integer type T. The params are
+// interpreted as described by Sequence.Init.
+func NewAtomic[T Integer](params ...T) (*AtomicSequence[T], error) {
+	seq := new(AtomicSequence[T])
+	err := seq.Init(params...)
+	return seq, err
+}
+
+// AtomicSequence is a Sequence whose methods are executed atomically with
+// respect to each other, making it safe for concurrent use. Because the
+// sync/atomic package does not operate on every integer type, the state of
+// the sequence is guarded by a mutex rather than by atomic instructions.
+type AtomicSequence[T Integer] struct {
+	mu  sync.RWMutex
+	seq Sequence[T]
+}
+
+// Init the sequence as described by Sequence.Init.
+func (s *AtomicSequence[T]) Init(params ...T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.Init(params...)
+}
+
+// Next atomically updates the state of the sequence and returns the next item
+// in the sequence as described by Sequence.Next.
+func (s *AtomicSequence[T]) Next() (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.Next()
+}
+
+// Restart the sequence as described by Sequence.Restart.
+func (s *AtomicSequence[T]) Restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.Restart()
+}
+
+// Update the sequence as described by Sequence.Update.
+func (s *AtomicSequence[T]) Update(val T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.Update(val)
+}
+
+// Current gives the current value of this sequence.
+func (s *AtomicSequence[T]) Current() (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq.Current()
+}
+
+// IsStarted returns the state of the sequence.
+func (s *AtomicSequence[T]) IsStarted() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq.IsStarted()
+}
+
+// State returns the current state of the sequence as described by
+// Sequence.State.
+func (s *AtomicSequence[T]) State() sequence.State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq.State()
+}
+
+// String returns a human readable representation of this sequence.
+func (s *AtomicSequence[T]) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq.String()
+}
+
+// Dump the sequence as described by Sequence.Dump.
+func (s *AtomicSequence[T]) Dump() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq.Dump()
+}
+
+// Load the sequence as described by Sequence.Load.
+func (s *AtomicSequence[T]) Load(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.Load(data)
+}
+
+// LoadStrict loads the sequence as described by Sequence.LoadStrict.
+func (s *AtomicSequence[T]) LoadStrict(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq.LoadStrict(data)
+}
@@ -0,0 +1,84 @@
+package generic
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+// Ensure that the AtomicSequence object implements the Incrementer interface.
+func TestInterfaceAtomic(t *testing.T) {
+	var _ Incrementer[int32] = &AtomicSequence[int32]{}
+}
+
+// Test that concurrent calls to Next never return duplicate values or
+// overflow the type.
+func TestAtomicConcurrentNext(t *testing.T) {
+	seq, err := NewAtomic[uint16]()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint16]bool)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				idx, err := seq.Next()
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				if seen[idx] {
+					t.Errorf("duplicate value %d", idx)
+				}
+				seen[idx] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(seen) != math.MaxUint16 {
+		t.Errorf("expected %d values got %d", math.MaxUint16, len(seen))
+	}
+}
+
+// Test the serialization and state of an atomic sequence.
+func TestAtomicSerialization(t *testing.T) {
+	seqa, err := NewAtomic[int32](-10, 10)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seqa.Next()
+	seqa.Update(5)
+
+	data, err := seqa.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seqb := &AtomicSequence[int32]{}
+	if err := seqb.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if idx, _ := seqb.Current(); idx != 5 {
+		t.Errorf("loaded sequence is at %d not 5", idx)
+	}
+
+	if !seqb.IsStarted() || seqb.String() != seqa.String() {
+		t.Error("loaded sequence does not match dumped sequence")
+	}
+
+	if err := seqb.Restart(); err != nil || seqb.IsStarted() {
+		t.Error("could not restart the loaded sequence")
+	}
+}
@@ -0,0 +1,415 @@
+// Package generic provides sequences over any Go integer type. The root
+// sequence package is hard-wired to uint64 values between 1 and the largest
+// uint64 value; the Sequence type in this package can instead be used to model
+// int32 primary keys, uint16 packet identifiers or ranges that include zero
+// and negative numbers.
+//
+// The API mirrors the sequence.Incrementer interface, with values of type T
+// rather than uint64:
+//
+//     seq, err := generic.New[int32]()      // count by 1 from 1 to math.MaxInt32
+//     seq, err := generic.New[int8](-10, 10) // count by 1 from -10 to 10
+//     seq, err := generic.New[int64](0, -100, -5) // count down by 5 from 0
+//     idx, err := seq.Next()
+//
+// Unlike the root package, every arithmetic operation is checked for overflow
+// so a sequence will return an error rather than wrap around its type.
+package generic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"unsafe"
+
+	"github.com/bbengfort/sequence"
+)
+
+//===========================================================================
+// Sequence Structs and Interfaces
+//===========================================================================
+
+// Integer is a constraint that permits any integer type, equivalent to the
+// constraints.Integer type of golang.org/x/exp.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Incrementer defines the interface for sequence-like objects of any integer
+// type. It is identical to the sequence.Incrementer interface except that
+// values are of type T.
+type Incrementer[T Integer] interface {
+	Init(params ...T) error // Initialize the Incrementer with values
+	Next() (T, error)       // Get the next value in the sequence and update
+	Restart() error         // Restarts the sequence if possible
+	Update(val T) error     // Update the state of the Incrementer
+	Current() (T, error)    // Returns the current value of the Incrementer
+	IsStarted() bool        // Returns the state of the Incrementer
+	String() string         // Returns a string representation of the state
+	Load(data []byte) error // Load the sequence from a serialized representation
+	Dump() ([]byte, error)  // Dump the sequence to a serialized representation
+}
+
+// Sequence implements an AutoIncrement counter over the integer type T. By
+// default a Sequence counts by 1 from 1 to the maximum value of T; other
+// ranges and steps are specified with New or Init. For signed types the step
+// may be negative, in which case the sequence counts down from the first
+// value to the second value.
+type Sequence[T Integer] struct {
+	current     T    // The current value of the sequence
+	increment   T    // The value to increment by (may be negative for signed types)
+	minvalue    T    // The minimum value of the counter
+	maxvalue    T    // The maximum value of the counter
+	started     bool // Flag that indicates if the sequence has issued a value
+	initialized bool // Flag that indicates if the sequence has been initialized
+}
+
+// New constructs a Sequence over the integer type T. The params are
+// interpreted as described by Init.
+func New[T Integer](params ...T) (*Sequence[T], error) {
+	seq := new(Sequence[T])
+	err := seq.Init(params...)
+	return seq, err
+}
+
+// MinimumBound returns the default minimum value of a Sequence of type T,
+// which is 1 for every integer type as with the root sequence package.
+func MinimumBound[T Integer]() T {
+	return 1
+}
+
+// MaximumBound returns the default maximum value of a Sequence of type T,
+// which is the largest value representable by T.
+func MaximumBound[T Integer]() T {
+	var zero T
+	if signed[T]() {
+		return T(1)<<(unsafe.Sizeof(zero)*8-1) - 1
+	}
+	return ^zero
+}
+
+//===========================================================================
+// Sequence Interaction Methods
+//===========================================================================
+
+// Init a sequence based on the number and order of the params.
+//
+//     seq.Init()              // count by 1 from 1 to MaximumBound[T]()
+//     seq.Init(100)           // count by 1 from 1 until 100
+//     seq.Init(-10, 100)      // count by 1 from -10 until 100
+//     seq.Init(100, -100, -2) // count down by 2 from 100 until -100
+//
+// Both endpoints of these ranges are inclusive. If the step is negative, the
+// first value must be greater than or equal to the second value, otherwise
+// the first value must be less than or equal to the second value. As with the
+// root package, Init returns an error if the sequence is already initialized.
+func (s *Sequence[T]) Init(params ...T) error {
+	if s.initialized {
+		return errors.New("cannot re-initialize a sequence object")
+	}
+
+	switch len(params) {
+	case 0:
+		s.increment = 1
+		s.minvalue = MinimumBound[T]()
+		s.maxvalue = MaximumBound[T]()
+	case 1:
+		if params[0] < MinimumBound[T]() {
+			return errors.New("must specify a maximal value greater than 0")
+		}
+
+		s.increment = 1
+		s.minvalue = MinimumBound[T]()
+		s.maxvalue = params[0]
+	case 2:
+		if params[1] < params[0] {
+			return errors.New("for a positive increment, the maximum value must be greater than or equal to the minimum value")
+		}
+
+		s.increment = 1
+		s.minvalue = params[0]
+		s.maxvalue = params[1]
+	case 3:
+		if params[2] == 0 {
+			return errors.New("must have a non-zero step to increment by")
+		}
+
+		if params[2] < 0 {
+			if params[0] < params[1] {
+				return errors.New("for a negative increment, the first value must be greater than or equal to the second value")
+			}
+
+			s.minvalue = params[1]
+			s.maxvalue = params[0]
+		} else {
+			if params[1] < params[0] {
+				return errors.New("for a positive increment, the second value must be greater than or equal to the first value")
+			}
+
+			s.minvalue = params[0]
+			s.maxvalue = params[1]
+		}
+
+		s.increment = params[2]
+	default:
+		return errors.New("too many arguments specified")
+	}
+
+	s.current = s.first()
+	s.started = false
+	s.initialized = true
+	return nil
+}
+
+// Next updates the state of the Sequence and returns the next item in the
+// sequence. It returns an error wrapping sequence.ErrExhausted if the next
+// value would be beyond the bounds of the sequence or would overflow T, in
+// which case the state is unchanged.
+func (s *Sequence[T]) Next() (T, error) {
+	if !s.initialized {
+		return 0, errors.New("sequence has not been initialized")
+	}
+
+	if !s.started {
+		s.started = true
+		return s.current, nil
+	}
+
+	next, ok := add(s.current, s.increment)
+	if s.increment > 0 && (!ok || next > s.maxvalue) {
+		return 0, fmt.Errorf("%w: reached maximum bound of sequence", sequence.ErrExhausted)
+	}
+
+	if s.increment < 0 && (!ok || next < s.minvalue) {
+		return 0, fmt.Errorf("%w: reached minimum bound of the sequence", sequence.ErrExhausted)
+	}
+
+	s.current = next
+	return s.current, nil
+}
+
+// Restart the sequence so that the next value is the first value of the
+// range. This is the only method that violates the monotonically increasing
+// or decreasing rule; use with care.
+func (s *Sequence[T]) Restart() error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	s.current = s.first()
+	s.started = false
+	return nil
+}
+
+// Update the sequence to the specified value, which must be within the bounds
+// of the sequence. If the update value violates the monotonically increasing
+// or decreasing rule, an error is returned.
+func (s *Sequence[T]) Update(val T) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	if val < s.minvalue || val > s.maxvalue {
+		return errors.New("cannot update sequence to a value outside of its bounds")
+	}
+
+	if s.started {
+		if s.increment > 0 && val < s.current {
+			return errors.New("cannot decrease monotonically increasing sequence")
+		}
+
+		if s.increment < 0 && val > s.current {
+			return errors.New("cannot increase monotonically decreasing sequence")
+		}
+	}
+
+	s.current = val
+	s.started = true
+	return nil
+}
+
+//===========================================================================
+// Sequence State Methods
+//===========================================================================
+
+// Current value of the sequence. Current will return an error if the sequence
+// has not been started or initialized.
+func (s *Sequence[T]) Current() (T, error) {
+	if !s.initialized {
+		return 0, errors.New("sequence has not been initialized")
+	}
+
+	if !s.started {
+		return 0, errors.New("sequence has not been started")
+	}
+
+	return s.current, nil
+}
+
+// IsStarted returns true if the sequence is initialized and has issued at
+// least one value since it was initialized or restarted, including if it has
+// been exhausted.
+func (s *Sequence[T]) IsStarted() bool {
+	return s.initialized && s.started
+}
+
+// State returns the current state of the sequence as described by
+// sequence.State.
+func (s *Sequence[T]) State() sequence.State {
+	switch {
+	case !s.initialized:
+		return sequence.Uninitialized
+	case !s.started:
+		return sequence.Ready
+	}
+
+	next, ok := add(s.current, s.increment)
+	if !ok || next > s.maxvalue || next < s.minvalue {
+		return sequence.Exhausted
+	}
+	return sequence.Active
+}
+
+// String returns a human readable representation of the sequence.
+func (s *Sequence[T]) String() string {
+	d := fmt.Sprintf("incremented by %d between %d and %d", s.increment, s.minvalue, s.maxvalue)
+	switch s.State() {
+	case sequence.Active:
+		return fmt.Sprintf("Sequence at %d, %s", s.current, d)
+	case sequence.Exhausted:
+		return fmt.Sprintf("Exhausted Sequence at %d, %s", s.current, d)
+	default:
+		return fmt.Sprintf("Unstarted Sequence %s", d)
+	}
+}
+
+//===========================================================================
+// Sequence Serialization Methods
+//===========================================================================
+
+// Dump the sequence into a JSON binary representation for the current state.
+// The format is similar to the root sequence package, with values of type T,
+// but records whether the sequence has issued a value explicitly: the current
+// value of a sequence that has not been started is its first value. Every
+// initialized sequence can be dumped, including one whose range starts at
+// the bound of its type.
+func (s *Sequence[T]) Dump() ([]byte, error) {
+	if !s.initialized {
+		return nil, errors.New("cannot dump an uninitialized sequence")
+	}
+
+	data := make(map[string]any)
+	data["current"] = s.current
+	data["increment"] = s.increment
+	data["minvalue"] = s.minvalue
+	data["maxvalue"] = s.maxvalue
+	data["started"] = s.started
+
+	return json.Marshal(data)
+}
+
+// Load an uninitialized sequence from a JSON binary representation of the
+// state of another sequence of the same type. An error is returned if any of
+// the values cannot be represented by T. As in the root package, the state
+// is validated and a *sequence.InvariantError is returned if a field is
+// missing or violates an invariant; unknown fields are ignored.
+func (s *Sequence[T]) Load(data []byte) error {
+	return s.load(data, false)
+}
+
+// LoadStrict loads the sequence as described by Load, but also returns a
+// *sequence.InvariantError wrapping sequence.ErrUnknownField if the data has
+// fields that are not part of the format exported by Dump.
+func (s *Sequence[T]) LoadStrict(data []byte) error {
+	return s.load(data, true)
+}
+
+// The fields of the serialized state of a sequence.
+var stateFields = []string{"current", "increment", "minvalue", "maxvalue", "started"}
+
+// Load the sequence, rejecting unknown fields if strict.
+func (s *Sequence[T]) load(data []byte, strict bool) error {
+	if s.initialized {
+		return errors.New("cannot load into an initialized sequence")
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for _, field := range stateFields {
+		if _, ok := fields[field]; !ok {
+			return &sequence.InvariantError{Field: field, Err: sequence.ErrMissingField}
+		}
+	}
+
+	if strict {
+		var unknown []string
+		for field := range fields {
+			if !slices.Contains(stateFields, field) {
+				unknown = append(unknown, field)
+			}
+		}
+
+		if len(unknown) > 0 {
+			slices.Sort(unknown)
+			return &sequence.InvariantError{Field: unknown[0], Err: sequence.ErrUnknownField}
+		}
+	}
+
+	var loaded Sequence[T]
+	targets := []any{&loaded.current, &loaded.increment, &loaded.minvalue, &loaded.maxvalue, &loaded.started}
+	for i, field := range stateFields {
+		if err := json.Unmarshal(fields[field], targets[i]); err != nil {
+			return err
+		}
+	}
+
+	// Validate the invariants that Init establishes.
+	if loaded.increment == 0 {
+		return &sequence.InvariantError{Field: "increment", Err: sequence.ErrZeroStep}
+	}
+
+	if loaded.minvalue > loaded.maxvalue {
+		return &sequence.InvariantError{Field: "maxvalue", Err: sequence.ErrEmptyRange}
+	}
+
+	// A sequence that has not issued a value is at its first value.
+	if loaded.current < loaded.minvalue || loaded.current > loaded.maxvalue || (!loaded.started && loaded.current != loaded.first()) {
+		return &sequence.InvariantError{Field: "current", Err: sequence.ErrOutOfRange}
+	}
+
+	loaded.initialized = true
+	*s = loaded
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// The first value of the sequence depends on the direction of the step.
+func (s *Sequence[T]) first() T {
+	if s.increment < 0 {
+		return s.maxvalue
+	}
+	return s.minvalue
+}
+
+// Returns true if T is a signed integer type.
+func signed[T Integer]() bool {
+	var zero T
+	return ^zero < 0
+}
+
+// Adds a and b, returning false if the addition overflows T.
+func add[T Integer](a, b T) (T, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
@@ -0,0 +1,485 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bbengfort/sequence"
+)
+
+//===========================================================================
+// Basic Tests
+//===========================================================================
+
+// Ensure that the Sequence object implements the Incrementer interface.
+func TestInterface(t *testing.T) {
+	var _ Incrementer[int32] = &Sequence[int32]{}
+	var _ Incrementer[uint16] = &Sequence[uint16]{}
+}
+
+// Test the type-appropriate default bounds.
+func TestBounds(t *testing.T) {
+	checkBounds[int8](t, math.MaxInt8)
+	checkBounds[int16](t, math.MaxInt16)
+	checkBounds[int32](t, math.MaxInt32)
+	checkBounds[int64](t, math.MaxInt64)
+	checkBounds[uint8](t, math.MaxUint8)
+	checkBounds[uint16](t, math.MaxUint16)
+	checkBounds[uint32](t, math.MaxUint32)
+	checkBounds[uint64](t, math.MaxUint64)
+	checkBounds[int](t, math.MaxInt)
+	checkBounds[uint](t, math.MaxUint)
+}
+
+func checkBounds[T Integer](t *testing.T, max T) {
+	seq, err := New[T]()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if seq.minvalue != 1 || seq.maxvalue != max || seq.increment != 1 {
+		t.Errorf("incorrect default bounds for %T: %s", max, seq)
+	}
+}
+
+// Test the auto increment functionality of a small type.
+func TestNext(t *testing.T) {
+	seq, err := New[uint8]()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i := 1; i <= math.MaxUint8; i++ {
+		j, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if int(j) != i {
+			t.Fatalf("expected %d got %d", i, j)
+		}
+	}
+
+	// The sequence must not wrap around the type.
+	if _, err := seq.Next(); !errors.Is(err, sequence.ErrExhausted) {
+		t.Errorf("expected exhausted error after reaching the maximum uint8 got %v", err)
+	}
+
+	if idx, _ := seq.Current(); idx != math.MaxUint8 {
+		t.Errorf("failed Next modified the state to %d", idx)
+	}
+}
+
+// Test a range that includes negative values and zero.
+func TestSignedRange(t *testing.T) {
+	seq, err := New[int8](-3, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []int8{-3, -2, -1, 0, 1, 2, 3}
+	for _, e := range expected {
+		idx, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx != e {
+			t.Fatalf("expected %d got %d", e, idx)
+		}
+	}
+
+	if _, err := seq.Next(); !errors.Is(err, sequence.ErrExhausted) {
+		t.Errorf("expected exhausted error after reaching its maximum got %v", err)
+	}
+}
+
+// Test a negative step that counts down to the type minimum without overflow.
+func TestNegativeStep(t *testing.T) {
+	seq, err := New[int8](-100, math.MinInt8, -10)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []int8{-100, -110, -120}
+	for _, e := range expected {
+		idx, err := seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx != e {
+			t.Fatalf("expected %d got %d", e, idx)
+		}
+	}
+
+	if _, err := seq.Next(); !errors.Is(err, sequence.ErrExhausted) {
+		t.Errorf("expected exhausted error after reaching its minimum got %v", err)
+	}
+}
+
+// Test that a large step near the maximum returns an error rather than
+// overflowing the type.
+func TestOverflow(t *testing.T) {
+	seq, err := New[int16](math.MaxInt16-10, math.MaxInt16, 7)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seq.Next()
+	seq.Next()
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("sequence overflowed int16")
+	}
+
+	useq, err := New[uint64](1, math.MaxUint64, math.MaxUint64-1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, e := range []uint64{1, math.MaxUint64} {
+		if idx, _ := useq.Next(); idx != e {
+			t.Fatalf("expected %d got %d", e, idx)
+		}
+	}
+
+	if _, err := useq.Next(); err == nil {
+		t.Error("sequence overflowed uint64")
+	}
+}
+
+// Test that sequences can be iterated until they are exhausted, stopping on
+// sequence.ErrExhausted like the iterators of the sequence package.
+func TestExhausted(t *testing.T) {
+	tests := []struct {
+		params   []int16
+		expected int
+	}{
+		{nil, math.MaxInt16},
+		{[]int16{-5, 5, 3}, 4},
+		{[]int16{0, math.MinInt16, -10000}, 4},
+		{[]int16{math.MaxInt16 - 10, math.MaxInt16, 7}, 2},
+	}
+
+	for i, tt := range tests {
+		seq, err := NewAtomic(tt.params...)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		n := 0
+		for {
+			if _, err := seq.Next(); err != nil {
+				if !errors.Is(err, sequence.ErrExhausted) {
+					t.Errorf("test %d: expected exhausted error got %v", i, err)
+				}
+				break
+			}
+			n++
+		}
+
+		if n != tt.expected {
+			t.Errorf("test %d: expected %d values got %d", i, tt.expected, n)
+		}
+	}
+}
+
+// Test bad Init arguments.
+func TestBadInit(t *testing.T) {
+	tests := [][]int32{
+		{0},
+		{-1},
+		{10, 1},
+		{1, 10, 0},
+		{1, 10, -1},
+		{10, 1, 1},
+		{1, 2, 3, 4},
+	}
+
+	for _, params := range tests {
+		if _, err := New[int32](params...); err == nil {
+			t.Errorf("expected error initializing with %v", params)
+		}
+	}
+
+	seq, _ := New[int32]()
+	if err := seq.Init(); err == nil {
+		t.Error("sequence allowed re-initialization")
+	}
+}
+
+// Test the restart functionality.
+func TestRestart(t *testing.T) {
+	seq, err := New[int64](-5, 5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := (&Sequence[int64]{}).Restart(); err == nil {
+		t.Error("restarted an uninitialized sequence")
+	}
+
+	for i := 0; i < 5; i++ {
+		seq.Next()
+	}
+
+	if err := seq.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if seq.IsStarted() {
+		t.Error("restart was not successful")
+	}
+
+	if idx, _ := seq.Next(); idx != -5 {
+		t.Errorf("restarted sequence began at %d", idx)
+	}
+}
+
+// Test the update functionality.
+func TestUpdate(t *testing.T) {
+	seq, err := New[int16](-100, 100)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := seq.Update(-10); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if idx, _ := seq.Next(); idx != -9 {
+		t.Errorf("expected -9 after update got %d", idx)
+	}
+
+	if err := seq.Update(-50); err == nil {
+		t.Error("allowed decrease of a monotonically increasing sequence")
+	}
+
+	if err := seq.Update(101); err == nil {
+		t.Error("allowed update outside of the bounds")
+	}
+
+	down, _ := New[int16](100, -100, -1)
+	down.Next()
+	if err := down.Update(101); err == nil {
+		t.Error("allowed update outside of the bounds")
+	}
+
+	if err := down.Update(50); err != nil {
+		t.Error(err.Error())
+	}
+
+	if err := down.Update(60); err == nil {
+		t.Error("allowed increase of a monotonically decreasing sequence")
+	}
+}
+
+// Test the current and is started functionality.
+func TestCurrent(t *testing.T) {
+	seq := &Sequence[uint32]{}
+	if _, err := seq.Current(); err == nil {
+		t.Error("current did not fail on an uninitialized sequence")
+	}
+
+	seq.Init(0, 10)
+	if _, err := seq.Current(); err == nil {
+		t.Error("current did not fail on an unstarted sequence")
+	}
+
+	if seq.IsStarted() {
+		t.Error("unstarted sequence says it's started?!")
+	}
+
+	// Zero is a valid value in the generic package.
+	if idx, _ := seq.Next(); idx != 0 {
+		t.Errorf("expected 0 got %d", idx)
+	}
+
+	if idx, err := seq.Current(); err != nil || idx != 0 {
+		t.Errorf("expected current 0 got %d (%v)", idx, err)
+	}
+
+	if !seq.IsStarted() {
+		t.Error("started sequence says it's not started?!")
+	}
+}
+
+// An example of the human readable state of a sequence.
+func ExampleSequence_String() {
+	seq, _ := New[int16](-100, 100, 2)
+
+	fmt.Println(seq)
+
+	seq.Next()
+	fmt.Println(seq)
+
+	// Output:
+	// Unstarted Sequence incremented by 2 between -100 and 100
+	// Sequence at -100, incremented by 2 between -100 and 100
+}
+
+//===========================================================================
+// Test Sequence Serialization
+//===========================================================================
+
+// Test the sequence state dump and load functionality at the type extremes.
+func TestSerialization(t *testing.T) {
+	seqa, err := New[int64](math.MinInt64, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seqa.Next()
+	data, err := seqa.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seqb := &Sequence[int64]{}
+	if err := seqb.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if *seqa != *seqb {
+		t.Error("loaded sequence does not match dumped sequence")
+	}
+
+	if err := seqb.Load(data); err == nil {
+		t.Error("loaded into an initialized sequence")
+	}
+
+	// Values that do not fit in the type cannot be loaded.
+	if err := (&Sequence[int8]{}).Load(data); err == nil {
+		t.Error("loaded int64 values into an int8 sequence")
+	}
+
+	if err := (&Sequence[int8]{}).Load([]byte(`{"current":1,"started":true}`)); err == nil {
+		t.Error("loaded improperly formatted data")
+	}
+
+	// State that violates the invariants of the sequence cannot be loaded.
+	bad := map[string]error{
+		`{"current":1,"increment":0,"minvalue":-10,"maxvalue":10,"started":true}`:   sequence.ErrZeroStep,
+		`{"current":1,"increment":1,"minvalue":10,"maxvalue":-10,"started":true}`:   sequence.ErrEmptyRange,
+		`{"current":11,"increment":-1,"minvalue":-10,"maxvalue":10,"started":true}`: sequence.ErrOutOfRange,
+		`{"current":1,"increment":-1,"minvalue":-10,"maxvalue":10,"started":false}`: sequence.ErrOutOfRange,
+		`{"current":1,"increment":1,"minvalue":-10,"maxvalue":10}`:                  sequence.ErrMissingField,
+	}
+
+	for data, expected := range bad {
+		if err := (&Sequence[int8]{}).Load([]byte(data)); !errors.Is(err, expected) {
+			t.Errorf("expected %s loading %s, got %v", expected, data, err)
+		}
+	}
+
+	strict := []byte(`{"current":1,"increment":1,"minvalue":-10,"maxvalue":10,"started":true,"extra":0}`)
+	if err := (&Sequence[int8]{}).Load(strict); err != nil {
+		t.Errorf("expected unknown fields to be ignored, got %s", err)
+	}
+
+	if err := (&Sequence[int8]{}).LoadStrict(strict); !errors.Is(err, sequence.ErrUnknownField) {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+}
+
+// Test that every state round trips through Dump and Load.
+func TestStateDumpLoad(t *testing.T) {
+	expected := []sequence.State{sequence.Ready, sequence.Active, sequence.Active, sequence.Exhausted}
+	for n, state := range expected {
+		up, _ := New[int8](-1, 1)
+		down, _ := New[int16](1, -1, -1)
+		aseq, _ := NewAtomic[uint8](1, 3)
+		for i := 0; i < n; i++ {
+			up.Next()
+			down.Next()
+			aseq.Next()
+		}
+
+		if up.State() != state || down.State() != state || aseq.State() != state {
+			t.Errorf("expected %s sequences, got %s, %s and %s", state, up.State(), down.State(), aseq.State())
+		}
+
+		checkDumpLoad(t, up, new(Sequence[int8]))
+		checkDumpLoad(t, down, new(Sequence[int16]))
+		checkDumpLoad(t, aseq, new(AtomicSequence[uint8]))
+	}
+
+	// Sequences whose range starts at the bound of their type.
+	for n := 0; n < 3; n++ {
+		zero, _ := New[uint16](0, math.MaxUint16)
+		full, _ := New[int8](math.MinInt8, math.MaxInt8)
+		top, _ := New[int64](math.MaxInt64, math.MinInt64, -1)
+		for i := 0; i < n; i++ {
+			zero.Next()
+			full.Next()
+			top.Next()
+		}
+
+		checkDumpLoad(t, zero, new(Sequence[uint16]))
+		checkDumpLoad(t, full, new(Sequence[int8]))
+		checkDumpLoad(t, top, new(Sequence[int64]))
+	}
+
+	if _, err := new(Sequence[int8]).Dump(); err == nil {
+		t.Error("dumped an uninitialized sequence")
+	}
+}
+
+func checkDumpLoad[T Integer](t *testing.T, seq, loaded interface {
+	Incrementer[T]
+	State() sequence.State
+}) {
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatalf("could not dump %s sequence %s: %s", seq.State(), seq, err)
+	}
+
+	if err := loaded.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if loaded.State() != seq.State() || loaded.String() != seq.String() {
+		t.Errorf("expected loaded sequence to be %s, got %s", seq, loaded)
+	}
+
+	// The loaded sequence must continue from the same position.
+	expected, eerr := seq.Next()
+	if val, err := loaded.Next(); val != expected || (err == nil) != (eerr == nil) {
+		t.Errorf("expected next value %d (%v) got %d (%v)", expected, eerr, val, err)
+	}
+}
+
+// Write a sequence to disk to be loaded later.
+func ExampleSequence_Dump() {
+	seq, _ := New[int32](-10, 10)
+
+	for i := 0; i < 5; i++ {
+		seq.Next()
+	}
+
+	data, _ := seq.Dump()
+	fmt.Println(string(data))
+
+	// Output:
+	// {"current":-6,"increment":1,"maxvalue":10,"minvalue":-10,"started":true}
+}
+
+//===========================================================================
+// Benchmarks
+//===========================================================================
+
+func BenchmarkSequence(b *testing.B) {
+	seq, err := New[int64]()
+	if err != nil {
+		b.Error(err.Error())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seq.Next()
+	}
+	b.ReportAllocs()
+}
@@ -0,0 +1,174 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// Interleave configures one of several writers that each issue their own
+// non-overlapping values without coordination, in the same way as MySQL's
+// auto_increment_increment and auto_increment_offset. Every value issued by
+// the writer is congruent to Offset modulo Nodes, so writers with different
+// offsets in a cluster of the same size never issue the same value:
+//
+//     seq, err := sequence.Interleave{Nodes: 3, Offset: 1}.New() // 4, 7, 10, ...
+//     seq, err := sequence.Interleave{Nodes: 3, Offset: 2}.New() // 5, 8, 11, ...
+//     seq, err := sequence.Interleave{Nodes: 3, Offset: 3}.New() // 3, 6, 9, ...
+//
+// Because the minimum value of a Sequence cannot be less than its step,
+// writers start at their first value greater than or equal to Nodes.
+//
+// To resize the cluster, every writer must stop issuing values from its old
+// configuration and the largest value issued by any writer must be agreed as
+// the After value of the new configurations, e.g. with ResizeInterleaved.
+type Interleave struct {
+	Nodes    uint64 // The number of writers in the cluster
+	Offset   uint64 // The offset of this writer, from 1 to Nodes
+	After    uint64 // Only values greater than After are issued
+	MaxValue uint64 // The maximum value issued, MaximumBound if zero
+}
+
+// NewInterleaved returns a Sequence for the writer with the offset in a
+// cluster of the given number of nodes. It is shorthand for
+// Interleave{Nodes: nodes, Offset: offset}.New().
+func NewInterleaved(nodes, offset uint64) (*Sequence, error) {
+	return Interleave{Nodes: nodes, Offset: offset}.New()
+}
+
+// ResizeInterleaved returns the configurations of every writer in a resized
+// cluster of the given number of nodes. After must be greater than or equal
+// to every value issued by the writers of the old cluster, which must stop
+// issuing values before the new configurations are used.
+func ResizeInterleaved(nodes, after uint64) []Interleave {
+	configs := make([]Interleave, 0, nodes)
+	for offset := uint64(1); offset <= nodes; offset++ {
+		configs = append(configs, Interleave{Nodes: nodes, Offset: offset, After: after})
+	}
+	return configs
+}
+
+// Validate returns an error if the configuration is not valid or if it does
+// not allow the writer to issue any values.
+func (c Interleave) Validate() error {
+	if c.Nodes == 0 {
+		return errors.New("an interleaved cluster must have at least one node")
+	}
+
+	if c.Offset == 0 || c.Offset > c.Nodes {
+		return fmt.Errorf("offset %d must be between 1 and the number of nodes %d", c.Offset, c.Nodes)
+	}
+
+	if c.MaxValue > MaximumBound {
+		return errors.New("the maximum value cannot be greater than the maximum bound")
+	}
+
+	if _, ok := c.first(); !ok {
+		return fmt.Errorf("node %d of %d has no values between %d and %d", c.Offset, c.Nodes, c.After, c.max())
+	}
+	return nil
+}
+
+// New returns a Sequence that issues the values of the writer.
+func (c Interleave) New() (*Sequence, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	first, _ := c.first()
+	return New(first, c.max(), c.Nodes)
+}
+
+// Returns the maximum value of the configuration.
+func (c Interleave) max() uint64 {
+	if c.MaxValue == 0 {
+		return MaximumBound
+	}
+	return c.MaxValue
+}
+
+// Returns the first value of the configuration, which is the smallest value
+// greater than After and not less than Nodes that is congruent to Offset
+// modulo Nodes, and false if there is no such value before the maximum.
+func (c Interleave) first() (uint64, bool) {
+	base := c.Nodes
+	if c.After >= base {
+		after, carry := bits.Add64(c.After, 1, 0)
+		if carry != 0 {
+			return 0, false
+		}
+		base = after
+	}
+
+	delta := (c.Offset%c.Nodes + c.Nodes - base%c.Nodes) % c.Nodes
+	if base > c.max() || delta > c.max()-base {
+		return 0, false
+	}
+	return base + delta, true
+}
+
+// CheckInterleaved returns an error if any of the configurations are invalid
+// or if any two of them could issue the same value, e.g. because two writers
+// share an offset or because the configurations of a resized cluster overlap
+// with those of the old cluster. Configurations of the old cluster should
+// have their MaxValue set to the After value of the new cluster.
+func CheckInterleaved(configs ...Interleave) error {
+	for i, c := range configs {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("configuration %d: %w", i, err)
+		}
+	}
+
+	for i := range configs {
+		for j := i + 1; j < len(configs); j++ {
+			if val, ok := collision(configs[i], configs[j]); ok {
+				return fmt.Errorf("configurations %d and %d both issue %d", i, j, val)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the smallest value issued by both valid configurations, if any,
+// using the Chinese remainder theorem.
+func collision(a, b Interleave) (uint64, bool) {
+	firstA, _ := a.first()
+	firstB, _ := b.first()
+
+	lo, hi := max(firstA, firstB), min(a.max(), b.max())
+	if lo > hi {
+		return 0, false
+	}
+
+	// Solve x = a.Offset (mod a.Nodes) and x = b.Offset (mod b.Nodes).
+	n, m := new(big.Int).SetUint64(a.Nodes), new(big.Int).SetUint64(b.Nodes)
+	ra, rb := new(big.Int).SetUint64(a.Offset%a.Nodes), new(big.Int).SetUint64(b.Offset%b.Nodes)
+
+	g := new(big.Int).GCD(nil, nil, n, m)
+	diff := new(big.Int).Sub(rb, ra)
+	if new(big.Int).Mod(diff, g).Sign() != 0 {
+		return 0, false
+	}
+
+	// x = ra + n * k where k = (diff / g) * inverse(n / g) mod (m / g).
+	mg := new(big.Int).Quo(m, g)
+	k := new(big.Int).Quo(diff, g)
+	if mg.Cmp(big.NewInt(1)) > 0 {
+		k.Mul(k, new(big.Int).ModInverse(new(big.Int).Quo(n, g), mg))
+	}
+	k.Mod(k, mg)
+
+	lcm := new(big.Int).Mul(n, mg)
+	x := new(big.Int).Add(ra, new(big.Int).Mul(n, k))
+
+	// Find the smallest solution that is greater than or equal to lo.
+	blo := new(big.Int).SetUint64(lo)
+	offset := new(big.Int).Sub(x, blo)
+	x.Add(blo, offset.Mod(offset, lcm))
+
+	if !x.IsUint64() || x.Uint64() > hi {
+		return 0, false
+	}
+	return x.Uint64(), true
+}
@@ -0,0 +1,162 @@
+package sequence
+
+import (
+	"math"
+	"testing"
+)
+
+// Test that the writers of a cluster issue non-overlapping values.
+func TestInterleaved(t *testing.T) {
+	tests := []struct {
+		offset uint64
+		first  []uint64
+	}{
+		{1, []uint64{4, 7, 10}},
+		{2, []uint64{5, 8, 11}},
+		{3, []uint64{3, 6, 9}},
+	}
+
+	seen := make(map[uint64]bool)
+	for _, tc := range tests {
+		seq, err := NewInterleaved(3, tc.offset)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for i := 0; i < 1000; i++ {
+			val, err := seq.Next()
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if i < len(tc.first) && val != tc.first[i] {
+				t.Errorf("expected node %d to issue %d got %d", tc.offset, tc.first[i], val)
+			}
+
+			if val%3 != tc.offset%3 {
+				t.Fatalf("node %d issued %d", tc.offset, val)
+			}
+
+			if seen[val] {
+				t.Fatalf("value %d issued by two nodes", val)
+			}
+			seen[val] = true
+		}
+	}
+
+	// A single node issues every value.
+	seq, _ := NewInterleaved(1, 1)
+	if val, _ := seq.Next(); val != 1 {
+		t.Errorf("expected 1 got %d", val)
+	}
+}
+
+// Test that invalid configurations are rejected.
+func TestInterleavedErrors(t *testing.T) {
+	for _, c := range []Interleave{
+		{Nodes: 0, Offset: 1},
+		{Nodes: 3, Offset: 0},
+		{Nodes: 3, Offset: 4},
+		{Nodes: 3, Offset: 1, MaxValue: MaximumBound + 1},
+		{Nodes: 3, Offset: 1, MaxValue: 3},
+		{Nodes: 3, Offset: 1, After: 10, MaxValue: 12},
+		{Nodes: 3, Offset: 1, After: MaximumBound},
+		{Nodes: 3, Offset: 1, After: math.MaxUint64},
+	} {
+		if _, err := c.New(); err == nil {
+			t.Errorf("created a sequence from invalid configuration %+v", c)
+		}
+	}
+}
+
+// Test that colliding configurations are detected.
+func TestCheckInterleaved(t *testing.T) {
+	if err := CheckInterleaved(ResizeInterleaved(5, 0)...); err != nil {
+		t.Errorf("a cluster collided with itself: %s", err)
+	}
+
+	if err := CheckInterleaved(Interleave{Nodes: 3, Offset: 1}, Interleave{Nodes: 3, Offset: 1}); err == nil {
+		t.Error("two nodes with the same offset did not collide")
+	}
+
+	// Clusters of different sizes collide if their offsets are congruent
+	// modulo the greatest common divisor of their sizes.
+	if err := CheckInterleaved(Interleave{Nodes: 4, Offset: 1}, Interleave{Nodes: 6, Offset: 3}); err == nil {
+		t.Error("expected odd values of clusters of 4 and 6 nodes to collide")
+	}
+
+	if err := CheckInterleaved(Interleave{Nodes: 4, Offset: 1}, Interleave{Nodes: 6, Offset: 2}); err != nil {
+		t.Errorf("odd and even values collided: %s", err)
+	}
+
+	// Collisions outside of the shared range are ignored.
+	a := Interleave{Nodes: 2, Offset: 1, MaxValue: 100}
+	b := Interleave{Nodes: 3, Offset: 1, After: 100}
+	if err := CheckInterleaved(a, b); err != nil {
+		t.Errorf("disjoint ranges collided: %s", err)
+	}
+
+	// Large clusters are checked without overflow: the only value both
+	// configurations share below the maximum bound is 7, which is before the
+	// first value of a, while a and c share every value of c, the first of which
+	// is 3 * 2^40 + 7 since nodes start at their first value after Nodes.
+	a = Interleave{Nodes: 1 << 40, Offset: 7, After: 1 << 39}
+	b = Interleave{Nodes: 3<<40 + 1, Offset: 7}
+	if val, ok := collision(a, b); ok {
+		t.Errorf("unexpected collision %d", val)
+	}
+
+	c := Interleave{Nodes: 1 << 41, Offset: 1<<40 + 7}
+	if val, ok := collision(a, c); !ok || val != 3<<40+7 {
+		t.Errorf("expected collision at %d got %d", uint64(3<<40+7), val)
+	}
+
+	if err := CheckInterleaved(Interleave{Nodes: 0}); err == nil {
+		t.Error("checked an invalid configuration")
+	}
+}
+
+// Test resizing a cluster without reusing values.
+func TestResizeInterleaved(t *testing.T) {
+	var old []*Sequence
+	for offset := uint64(1); offset <= 3; offset++ {
+		seq, _ := NewInterleaved(3, offset)
+		old = append(old, seq)
+	}
+
+	seen := make(map[uint64]bool)
+	var after uint64
+	for i := 0; i < 100; i++ {
+		for _, seq := range old {
+			val, _ := seq.Next()
+			seen[val] = true
+			after = max(after, val)
+		}
+	}
+
+	configs := ResizeInterleaved(5, after)
+	oldConfigs := []Interleave{{Nodes: 3, Offset: 1, MaxValue: after}, {Nodes: 3, Offset: 2, MaxValue: after}, {Nodes: 3, Offset: 3, MaxValue: after}}
+	if err := CheckInterleaved(append(oldConfigs, configs...)...); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, c := range configs {
+		seq, err := c.New()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for i := 0; i < 100; i++ {
+			val, _ := seq.Next()
+			if val <= after || seen[val] {
+				t.Fatalf("resized node %d reused value %d", c.Offset, val)
+			}
+			seen[val] = true
+		}
+	}
+
+	// Without a maximum, the old configurations collide with the new ones.
+	if err := CheckInterleaved(Interleave{Nodes: 3, Offset: 1}, configs[0]); err == nil {
+		t.Error("unbounded old configuration did not collide")
+	}
+}
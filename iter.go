@@ -0,0 +1,102 @@
+package sequence
+
+import (
+	"errors"
+	"iter"
+)
+
+// All returns an iterator over the remaining values of the Incrementer. The
+// iterator stops cleanly when the sequence is exhausted. Any other error
+// returned by Next is yielded with a zero value as the final pair:
+//
+//     for idx, err := range sequence.All(seq) {
+//         if err != nil {
+//             return err
+//         }
+//         fmt.Println(idx)
+//     }
+//
+// Each iteration calls Next, so values are consumed from the sequence as they
+// are yielded and breaking out of the loop leaves the remaining values.
+func All(inc Incrementer) iter.Seq2[uint64, error] {
+	return func(yield func(uint64, error) bool) {
+		for {
+			val, err := inc.Next()
+			if err != nil {
+				if !errors.Is(err, ErrExhausted) {
+					yield(0, err)
+				}
+				return
+			}
+
+			if !yield(val, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns an iterator over at most the next n values of the Incrementer,
+// stopping early if the sequence is exhausted. Errors are reported as they
+// are by All.
+func Take(inc Incrementer, n uint64) iter.Seq2[uint64, error] {
+	return func(yield func(uint64, error) bool) {
+		if n == 0 {
+			return
+		}
+
+		var taken uint64
+		for val, err := range All(inc) {
+			if !yield(val, err) || err != nil {
+				return
+			}
+
+			if taken++; taken == n {
+				return
+			}
+		}
+	}
+}
+
+// Until returns an iterator over the values of a monotonically increasing
+// Incrementer up to and including v, stopping early if the sequence is
+// exhausted. Errors are reported as they are by All. If the sequence steps
+// over v, the first value greater than v is consumed but not yielded.
+func Until(inc Incrementer, v uint64) iter.Seq2[uint64, error] {
+	return func(yield func(uint64, error) bool) {
+		for val, err := range All(inc) {
+			if err == nil && val > v {
+				return
+			}
+
+			if !yield(val, err) || err != nil || val == v {
+				return
+			}
+		}
+	}
+}
+
+// Values adapts an iterator returned by All, Take or Until into an iterator
+// over its values alone, for use with functions that accept an iter.Seq such
+// as slices.Collect. If an error is yielded, iteration stops and the error is
+// stored in err, which should be checked once iteration is complete:
+//
+//     var err error
+//     ids := slices.Collect(sequence.Values(sequence.Take(seq, 10), &err))
+//     if err != nil {
+//         return err
+//     }
+func Values(seq iter.Seq2[uint64, error], err *error) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for val, e := range seq {
+			if e != nil {
+				*err = e
+				return
+			}
+
+			if !yield(val) {
+				return
+			}
+		}
+	}
+}
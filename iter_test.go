@@ -0,0 +1,120 @@
+package sequence
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Test iterating over all values of a bounded sequence.
+func TestAll(t *testing.T) {
+	seq, _ := New(2, 10, 2)
+
+	var vals []uint64
+	for val, err := range All(seq) {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		vals = append(vals, val)
+	}
+
+	if !slices.Equal(vals, []uint64{2, 4, 6, 8, 10}) {
+		t.Errorf("unexpected values %v", vals)
+	}
+
+	// The sequence is exhausted so no more values are yielded.
+	for val := range All(seq) {
+		t.Errorf("yielded %d from an exhausted sequence", val)
+	}
+
+	if _, err := seq.Next(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("expected exhausted error got %v", err)
+	}
+}
+
+// Test that breaking out of the loop leaves the remaining values.
+func TestAllBreak(t *testing.T) {
+	seq, _ := New()
+	for val := range All(seq) {
+		if val == 5 {
+			break
+		}
+	}
+
+	if idx, _ := seq.Current(); idx != 5 {
+		t.Errorf("expected the sequence at 5 got %d", idx)
+	}
+}
+
+// Test that errors other than exhaustion are yielded.
+func TestAllError(t *testing.T) {
+	seq, _ := New()
+	rl, _ := NewRateLimited(seq, 3, time.Minute)
+	rl.SetClock(newMockClock())
+
+	var vals []uint64
+	var errs []error
+	for val, err := range All(rl) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vals = append(vals, val)
+	}
+
+	if len(vals) != 3 || len(errs) != 1 || errs[0] != ErrRateLimited {
+		t.Errorf("unexpected values %v and errors %v", vals, errs)
+	}
+}
+
+// Test taking a fixed number of values.
+func TestTake(t *testing.T) {
+	seq, _ := New(5)
+
+	var err error
+	if vals := slices.Collect(Values(Take(seq, 3), &err)); !slices.Equal(vals, []uint64{1, 2, 3}) || err != nil {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+
+	if vals := slices.Collect(Values(Take(seq, 0), &err)); len(vals) != 0 {
+		t.Errorf("took values %v", vals)
+	}
+
+	if vals := slices.Collect(Values(Take(seq, 10), &err)); !slices.Equal(vals, []uint64{4, 5}) || err != nil {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+}
+
+// Test iterating until a value is reached.
+func TestUntil(t *testing.T) {
+	seq, _ := New(3, 99, 3)
+
+	var err error
+	if vals := slices.Collect(Values(Until(seq, 9), &err)); !slices.Equal(vals, []uint64{3, 6, 9}) || err != nil {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+
+	// Stepping over the value stops before it.
+	if vals := slices.Collect(Values(Until(seq, 16), &err)); !slices.Equal(vals, []uint64{12, 15}) || err != nil {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+
+	// The sequence is exhausted before the value is reached.
+	vals := slices.Collect(Values(Until(seq, 1000), &err))
+	if len(vals) != 27 || vals[0] != 21 || vals[26] != 99 || err != nil {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+}
+
+// Test that Values stores the error and stops.
+func TestValuesError(t *testing.T) {
+	seq, _ := New()
+	rl, _ := NewRateLimited(seq, 2, time.Minute)
+	rl.SetClock(newMockClock())
+
+	var err error
+	if vals := slices.Collect(Values(Take(rl, 5), &err)); len(vals) != 2 || err != ErrRateLimited {
+		t.Errorf("unexpected values %v (%v)", vals, err)
+	}
+}
@@ -0,0 +1,339 @@
+// Package metrics instruments sequences with Prometheus metrics, providing
+// visibility into how quickly sequences are burning through their range.
+// Any sequence.Incrementer can be instrumented by registering it with a
+// Registry, which is a prometheus.Collector for all of its sequences:
+//
+//     reg := metrics.NewRegistry()
+//     prometheus.MustRegister(reg)
+//
+//     base, err := sequence.New()
+//     seq, err := reg.Register("orders", base)
+//     idx, err := seq.Next()
+//
+// The following metrics are collected, labeled by the name of the sequence:
+//
+//     sequence_calls_total{sequence,method}   calls to Next, Update and Restart
+//     sequence_errors_total{sequence,method}  errors from Next, Update and Restart
+//     sequence_current_value{sequence}        the current value of started sequences
+//     sequence_remaining_values{sequence}     values Next can still return
+//     sequence_used_ratio{sequence}           fraction of the range used from 0 to 1
+//
+// The remaining and used metrics are only collected for sequences that
+// implement the sequence.Bounded interface.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bbengfort/sequence"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The methods whose calls and errors are counted.
+const (
+	methodNext    = "next"
+	methodUpdate  = "update"
+	methodRestart = "restart"
+)
+
+var methods = []string{methodNext, methodUpdate, methodRestart}
+
+// Metric descriptions shared by all registries.
+var (
+	callsDesc = prometheus.NewDesc(
+		"sequence_calls_total",
+		"Number of calls to state-changing sequence methods.",
+		[]string{"sequence", "method"}, nil,
+	)
+
+	errorsDesc = prometheus.NewDesc(
+		"sequence_errors_total",
+		"Number of errors returned by state-changing sequence methods.",
+		[]string{"sequence", "method"}, nil,
+	)
+
+	currentDesc = prometheus.NewDesc(
+		"sequence_current_value",
+		"The current value of the sequence.",
+		[]string{"sequence"}, nil,
+	)
+
+	remainingDesc = prometheus.NewDesc(
+		"sequence_remaining_values",
+		"The number of values the sequence can issue before it is exhausted.",
+		[]string{"sequence"}, nil,
+	)
+
+	usedDesc = prometheus.NewDesc(
+		"sequence_used_ratio",
+		"The fraction of the range of the sequence that has been used.",
+		[]string{"sequence"}, nil,
+	)
+)
+
+//===========================================================================
+// Instrumented Sequence
+//===========================================================================
+
+// Sequence wraps a sequence.Incrementer, counting calls to and errors from
+// its state-changing methods. The state of the wrapped sequence is read after
+// every call and the metrics are collected from that snapshot, so collecting
+// never waits for a call that blocks, e.g. on a rate limiter or a context.
+// Calls are passed to the wrapped sequence without locking, so it must be
+// safe for concurrent use if the instrumented sequence is used concurrently;
+// wrap it with sequence.Synchronized otherwise.
+type Sequence struct {
+	mu     sync.Mutex
+	name   string               // The name used to label the metrics
+	inc    sequence.Incrementer // The instrumented sequence
+	calls  map[string]*uint64   // Number of calls per method
+	errors map[string]*uint64   // Number of errors per method
+	gauges gauges               // The state of the sequence after the last call
+}
+
+// The state of an instrumented sequence that is reported by gauges.
+type gauges struct {
+	started   bool   // If false the current value is not reported
+	current   uint64 // The current value of the sequence
+	bounded   bool   // If false the remaining and used values are not reported
+	remaining uint64 // The number of values Next can still return
+	capacity  uint64 // The number of values in the range minus one
+}
+
+// Instrument wraps the Incrementer so that its usage is counted. The
+// instrumented sequence must be added to a Registry for it to be collected;
+// Registry.Register instruments and adds the sequence in one step.
+func Instrument(name string, inc sequence.Incrementer) *Sequence {
+	s := &Sequence{
+		name:   name,
+		inc:    inc,
+		calls:  make(map[string]*uint64, len(methods)),
+		errors: make(map[string]*uint64, len(methods)),
+	}
+
+	for _, method := range methods {
+		s.calls[method] = new(uint64)
+		s.errors[method] = new(uint64)
+	}
+
+	s.refresh()
+	return s
+}
+
+// Name returns the name that labels the metrics of the sequence.
+func (s *Sequence) Name() string {
+	return s.name
+}
+
+// Count a call to the method and an error if one occurred, then refresh the
+// gauges with the state of the sequence.
+func (s *Sequence) observe(method string, err error) {
+	atomic.AddUint64(s.calls[method], 1)
+	if err != nil {
+		atomic.AddUint64(s.errors[method], 1)
+	}
+	s.refresh()
+}
+
+// Read the state of the sequence into the gauges. The state is read with the
+// lock held so that the gauges are never replaced by an older reading.
+func (s *Sequence) refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var g gauges
+	if current, err := s.inc.Current(); err == nil {
+		g.started, g.current = true, current
+	}
+
+	if bounded, ok := s.inc.(sequence.Bounded); ok {
+		// The increment is zero if the sequence has not been initialized.
+		if minvalue, maxvalue, increment := bounded.Bounds(); increment != 0 {
+			g.bounded = true
+			g.remaining = bounded.Remaining()
+			g.capacity = (maxvalue - minvalue) / increment
+		}
+	}
+	s.gauges = g
+}
+
+// Init initializes the instrumented sequence.
+func (s *Sequence) Init(params ...uint64) error {
+	defer s.refresh()
+	return s.inc.Init(params...)
+}
+
+// Next returns the next value of the instrumented sequence.
+func (s *Sequence) Next() (uint64, error) {
+	val, err := s.inc.Next()
+	s.observe(methodNext, err)
+	return val, err
+}
+
+// NextContext returns the next value of the instrumented sequence, respecting
+// the context as described by sequence.NextContext.
+func (s *Sequence) NextContext(ctx context.Context) (uint64, error) {
+	val, err := sequence.NextContext(ctx, s.inc)
+	s.observe(methodNext, err)
+	return val, err
+}
+
+// ReserveContext reserves n values from the instrumented sequence as
+// described by sequence.ReserveContext. A reservation counts as one call.
+func (s *Sequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	vals, err := sequence.ReserveContext(ctx, s.inc, n)
+	s.observe(methodNext, err)
+	return vals, err
+}
+
+// Restart the instrumented sequence.
+func (s *Sequence) Restart() error {
+	err := s.inc.Restart()
+	s.observe(methodRestart, err)
+	return err
+}
+
+// Update the instrumented sequence.
+func (s *Sequence) Update(val uint64) error {
+	err := s.inc.Update(val)
+	s.observe(methodUpdate, err)
+	return err
+}
+
+// Current returns the current value of the instrumented sequence.
+func (s *Sequence) Current() (uint64, error) {
+	return s.inc.Current()
+}
+
+// IsStarted returns the state of the instrumented sequence.
+func (s *Sequence) IsStarted() bool {
+	return s.inc.IsStarted()
+}
+
+// String returns a human readable representation of the instrumented sequence.
+func (s *Sequence) String() string {
+	return fmt.Sprintf("%s: %s", s.name, s.inc)
+}
+
+// Dump the instrumented sequence.
+func (s *Sequence) Dump() ([]byte, error) {
+	return s.inc.Dump()
+}
+
+// Load the instrumented sequence.
+func (s *Sequence) Load(data []byte) error {
+	defer s.refresh()
+	return s.inc.Load(data)
+}
+
+// Collect the metrics of the sequence.
+func (s *Sequence) collect(ch chan<- prometheus.Metric) {
+	for _, method := range methods {
+		ch <- prometheus.MustNewConstMetric(callsDesc, prometheus.CounterValue,
+			float64(atomic.LoadUint64(s.calls[method])), s.name, method)
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue,
+			float64(atomic.LoadUint64(s.errors[method])), s.name, method)
+	}
+
+	s.mu.Lock()
+	g := s.gauges
+	s.mu.Unlock()
+
+	if g.started {
+		ch <- prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, float64(g.current), s.name)
+	}
+
+	if g.bounded {
+		capacity := float64(g.capacity) + 1
+		ch <- prometheus.MustNewConstMetric(remainingDesc, prometheus.GaugeValue, float64(g.remaining), s.name)
+		ch <- prometheus.MustNewConstMetric(usedDesc, prometheus.GaugeValue, 1-float64(g.remaining)/capacity, s.name)
+	}
+}
+
+//===========================================================================
+// Registry
+//===========================================================================
+
+// Registry is a named collection of instrumented sequences that implements
+// the prometheus.Collector interface. It is safe for concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	seqs map[string]*Sequence
+}
+
+// NewRegistry returns an empty registry of instrumented sequences.
+func NewRegistry() *Registry {
+	return &Registry{seqs: make(map[string]*Sequence)}
+}
+
+// Register instruments the Incrementer and adds it to the registry. The
+// instrumented sequence should be used in place of the Incrementer so that
+// its usage is counted. An error is returned if the name is already taken.
+func (r *Registry) Register(name string, inc sequence.Incrementer) (*Sequence, error) {
+	seq := Instrument(name, inc)
+	if err := r.Add(seq); err != nil {
+		return nil, err
+	}
+	return seq, nil
+}
+
+// Add an instrumented sequence to the registry. An error is returned if a
+// sequence with the same name has already been added.
+func (r *Registry) Add(seq *Sequence) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.seqs[seq.name]; ok {
+		return fmt.Errorf("sequence %q is already registered", seq.name)
+	}
+
+	r.seqs[seq.name] = seq
+	return nil
+}
+
+// Unregister removes the named sequence from the registry, returning true if
+// the sequence was registered.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.seqs[name]
+	delete(r.seqs, name)
+	return ok
+}
+
+// Names returns the sorted names of the sequences in the registry.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.seqs))
+	for name := range r.seqs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Describe implements prometheus.Collector.
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- callsDesc
+	ch <- errorsDesc
+	ch <- currentDesc
+	ch <- remainingDesc
+	ch <- usedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, seq := range r.seqs {
+		seq.collect(ch)
+	}
+}
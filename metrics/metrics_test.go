@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbengfort/sequence"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Ensure that the instrumented sequence implements the sequence interfaces.
+func TestInterface(t *testing.T) {
+	var _ sequence.Incrementer = &Sequence{}
+	var _ sequence.ContextIncrementer = &Sequence{}
+	var _ prometheus.Collector = &Registry{}
+}
+
+// Test registering and unregistering sequences.
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+
+	seq, _ := sequence.New()
+	if _, err := reg.Register("orders", seq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := reg.Register("orders", seq); err == nil {
+		t.Error("registered a duplicate sequence name")
+	}
+
+	reg.Register("invoices", new(sequence.AtomicSequence))
+	if names := reg.Names(); len(names) != 2 || names[0] != "invoices" || names[1] != "orders" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	if !reg.Unregister("orders") || reg.Unregister("orders") {
+		t.Error("could not unregister sequence")
+	}
+}
+
+// Test the collected metrics of a bounded sequence.
+func TestCollect(t *testing.T) {
+	reg := NewRegistry()
+
+	base, _ := sequence.New(1000)
+	seq, err := reg.Register("tickets", base)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i := 0; i < 800; i++ {
+		seq.Next()
+	}
+
+	seq.Update(10)
+	seq.Update(900)
+	seq.Restart()
+	seq.Update(900)
+
+	expected := `
+# HELP sequence_calls_total Number of calls to state-changing sequence methods.
+# TYPE sequence_calls_total counter
+sequence_calls_total{method="next",sequence="tickets"} 800
+sequence_calls_total{method="restart",sequence="tickets"} 1
+sequence_calls_total{method="update",sequence="tickets"} 3
+# HELP sequence_current_value The current value of the sequence.
+# TYPE sequence_current_value gauge
+sequence_current_value{sequence="tickets"} 900
+# HELP sequence_errors_total Number of errors returned by state-changing sequence methods.
+# TYPE sequence_errors_total counter
+sequence_errors_total{method="next",sequence="tickets"} 0
+sequence_errors_total{method="restart",sequence="tickets"} 0
+sequence_errors_total{method="update",sequence="tickets"} 1
+# HELP sequence_remaining_values The number of values the sequence can issue before it is exhausted.
+# TYPE sequence_remaining_values gauge
+sequence_remaining_values{sequence="tickets"} 100
+# HELP sequence_used_ratio The fraction of the range of the sequence that has been used.
+# TYPE sequence_used_ratio gauge
+sequence_used_ratio{sequence="tickets"} 0.9
+`
+
+	if err := testutil.CollectAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+// Test that errors are counted and that unstarted and unbounded sequences
+// only report the metrics that are available.
+func TestCollectPartial(t *testing.T) {
+	reg := NewRegistry()
+
+	base, _ := sequence.New(2)
+	seq, _ := reg.Register("small", base)
+	for i := 0; i < 4; i++ {
+		seq.Next()
+	}
+
+	reg.Register("unstarted", &unbounded{new(sequence.Sequence)})
+
+	expected := `
+# HELP sequence_errors_total Number of errors returned by state-changing sequence methods.
+# TYPE sequence_errors_total counter
+sequence_errors_total{method="next",sequence="small"} 2
+sequence_errors_total{method="restart",sequence="small"} 0
+sequence_errors_total{method="update",sequence="small"} 0
+sequence_errors_total{method="next",sequence="unstarted"} 0
+sequence_errors_total{method="restart",sequence="unstarted"} 0
+sequence_errors_total{method="update",sequence="unstarted"} 0
+# HELP sequence_used_ratio The fraction of the range of the sequence that has been used.
+# TYPE sequence_used_ratio gauge
+sequence_used_ratio{sequence="small"} 1
+`
+
+	if err := testutil.CollectAndCompare(reg, strings.NewReader(expected), "sequence_errors_total", "sequence_used_ratio"); err != nil {
+		t.Error(err.Error())
+	}
+
+	if n := testutil.CollectAndCount(reg, "sequence_current_value", "sequence_remaining_values"); n != 2 {
+		t.Errorf("expected only the small sequence current and remaining values, got %d metrics", n)
+	}
+}
+
+// An Incrementer that does not implement sequence.Bounded.
+type unbounded struct {
+	sequence.Incrementer
+}
+
+// An Incrementer whose NextContext blocks until the context is done.
+type blocking struct {
+	*sequence.Sequence
+	waiting chan struct{}
+}
+
+func (s *blocking) NextContext(ctx context.Context) (uint64, error) {
+	close(s.waiting)
+	<-ctx.Done()
+	return 0, sequence.WrapContextError(ctx.Err())
+}
+
+func (s *blocking) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	_, err := s.NextContext(ctx)
+	return nil, err
+}
+
+// Test that collecting does not wait for a call that is blocked.
+func TestCollectBlocked(t *testing.T) {
+	reg := NewRegistry()
+	base, _ := sequence.New(10)
+	base.Next()
+
+	inc := &blocking{Sequence: base, waiting: make(chan struct{})}
+	seq, _ := reg.Register("blocked", inc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := seq.NextContext(ctx)
+		done <- err
+	}()
+	<-inc.waiting
+
+	collected := make(chan int)
+	go func() {
+		collected <- testutil.CollectAndCount(reg, "sequence_current_value")
+	}()
+
+	select {
+	case n := <-collected:
+		if n != 1 {
+			t.Errorf("expected the current value to be collected, got %d metrics", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("collecting waited for a blocked call")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, sequence.ErrCanceled) {
+		t.Errorf("expected canceled error got %v", err)
+	}
+
+	expected := `
+# HELP sequence_errors_total Number of errors returned by state-changing sequence methods.
+# TYPE sequence_errors_total counter
+sequence_errors_total{method="next",sequence="blocked"} 1
+sequence_errors_total{method="restart",sequence="blocked"} 0
+sequence_errors_total{method="update",sequence="blocked"} 0
+`
+
+	if err := testutil.CollectAndCompare(reg, strings.NewReader(expected), "sequence_errors_total"); err != nil {
+		t.Error(err.Error())
+	}
+}
@@ -0,0 +1,244 @@
+package sequence
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Method identifies the method of an Incrementer intercepted by a Middleware.
+type Method string
+
+// The methods intercepted by middleware.
+const (
+	MethodNext    Method = "next"
+	MethodReserve Method = "reserve"
+	MethodUpdate  Method = "update"
+	MethodRestart Method = "restart"
+	MethodLoad    Method = "load"
+	MethodDump    Method = "dump"
+)
+
+// Call describes a single call to an Incrementer that is passed through the
+// hooks of a middleware chain. Before hooks see the arguments of the call and
+// After hooks additionally see its results; hooks may modify the call, e.g.
+// to replace the error, and may attach values to its context for later hooks.
+type Call struct {
+	Context context.Context // The context of the call, Background unless called with a context
+	Method  Method          // The method of the Incrementer that was called
+	Start   time.Time       // The time the call was made, before any hooks
+	Arg     uint64          // The value passed to Update or the number of values reserved
+	Value   uint64          // The value returned by Next
+	Values  []uint64        // The values returned by ReserveContext
+	Data    []byte          // The data passed to Load or returned by Dump
+	Err     error           // The error returned by the call or by a Before hook
+}
+
+// Middleware intercepts the state-changing calls to an Incrementer with hooks
+// that run before and after the call. If Before returns an error the call is
+// not made and the error is returned to the caller instead, which allows
+// middleware to reject calls or inject faults. Either hook may be nil.
+type Middleware struct {
+	Before func(call *Call) error // Called before the call, an error aborts the call
+	After  func(call *Call)       // Called after the call with its results
+}
+
+// ChainedSequence wraps any Incrementer with a chain of middleware that
+// intercepts Next, NextContext, ReserveContext, Update, Restart, Load and
+// Dump. Before hooks run in the order the middleware was chained and After
+// hooks run in reverse order, so the first middleware wraps all the others.
+// If a Before hook aborts the call, only the After hooks of the middleware
+// whose Before hooks already ran are called. Init, Current, IsStarted and
+// String are passed through to the underlying sequence without hooks.
+//
+// A ChainedSequence is safe for concurrent use if the underlying sequence and
+// all of the hooks are; wrap it with Synchronized otherwise.
+type ChainedSequence struct {
+	inc Incrementer  // The underlying sequence
+	mw  []Middleware // The middleware in the order the Before hooks are run
+}
+
+// Chain wraps the Incrementer with the middleware. The Incrementer must not
+// be nil and should not be used directly once it is chained.
+func Chain(inc Incrementer, mw ...Middleware) *ChainedSequence {
+	return &ChainedSequence{inc: inc, mw: append([]Middleware(nil), mw...)}
+}
+
+// Run the Before hooks, the call and the After hooks of the middleware that
+// ran, returning the error of the call.
+func (s *ChainedSequence) intercept(call *Call, invoke func(call *Call)) error {
+	call.Start = time.Now()
+
+	n := 0
+	for ; n < len(s.mw); n++ {
+		if before := s.mw[n].Before; before != nil {
+			if err := before(call); err != nil {
+				call.Err = err
+				break
+			}
+		}
+	}
+
+	if call.Err == nil {
+		invoke(call)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		if after := s.mw[i].After; after != nil {
+			after(call)
+		}
+	}
+	return call.Err
+}
+
+//===========================================================================
+// Chained Interaction Methods
+//===========================================================================
+
+// Init initializes the underlying sequence.
+func (s *ChainedSequence) Init(params ...uint64) error {
+	return s.inc.Init(params...)
+}
+
+// Next returns the next value of the underlying sequence.
+func (s *ChainedSequence) Next() (uint64, error) {
+	call := &Call{Context: context.Background(), Method: MethodNext}
+	err := s.intercept(call, func(call *Call) {
+		call.Value, call.Err = s.inc.Next()
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return call.Value, nil
+}
+
+// NextContext returns the next value of the underlying sequence unless the
+// context is done. The context is passed to the hooks of the middleware.
+func (s *ChainedSequence) NextContext(ctx context.Context) (uint64, error) {
+	call := &Call{Context: ctx, Method: MethodNext}
+	err := s.intercept(call, func(call *Call) {
+		call.Value, call.Err = NextContext(call.Context, s.inc)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return call.Value, nil
+}
+
+// ReserveContext returns the next n values of the underlying sequence unless
+// the context is done. The context is passed to the hooks of the middleware.
+func (s *ChainedSequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	call := &Call{Context: ctx, Method: MethodReserve, Arg: n}
+	err := s.intercept(call, func(call *Call) {
+		call.Values, call.Err = ReserveContext(call.Context, s.inc, call.Arg)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return call.Values, nil
+}
+
+// Restart the underlying sequence.
+func (s *ChainedSequence) Restart() error {
+	call := &Call{Context: context.Background(), Method: MethodRestart}
+	return s.intercept(call, func(call *Call) {
+		call.Err = s.inc.Restart()
+	})
+}
+
+// Update the underlying sequence.
+func (s *ChainedSequence) Update(val uint64) error {
+	call := &Call{Context: context.Background(), Method: MethodUpdate, Arg: val}
+	return s.intercept(call, func(call *Call) {
+		call.Err = s.inc.Update(call.Arg)
+	})
+}
+
+//===========================================================================
+// Chained State Methods
+//===========================================================================
+
+// Current returns the current value of the underlying sequence.
+func (s *ChainedSequence) Current() (uint64, error) {
+	return s.inc.Current()
+}
+
+// IsStarted returns the state of the underlying sequence.
+func (s *ChainedSequence) IsStarted() bool {
+	return s.inc.IsStarted()
+}
+
+// String returns a human readable representation of the underlying sequence.
+func (s *ChainedSequence) String() string {
+	return s.inc.String()
+}
+
+//===========================================================================
+// Chained Serialization
+//===========================================================================
+
+// Dump the underlying sequence.
+func (s *ChainedSequence) Dump() ([]byte, error) {
+	call := &Call{Context: context.Background(), Method: MethodDump}
+	err := s.intercept(call, func(call *Call) {
+		call.Data, call.Err = s.inc.Dump()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return call.Data, nil
+}
+
+// Load the underlying sequence.
+func (s *ChainedSequence) Load(data []byte) error {
+	call := &Call{Context: context.Background(), Method: MethodLoad, Data: data}
+	return s.intercept(call, func(call *Call) {
+		call.Err = s.inc.Load(call.Data)
+	})
+}
+
+//===========================================================================
+// Logging Middleware
+//===========================================================================
+
+// LogMiddleware returns middleware that logs every intercepted call with the
+// logger, or with slog.Default() if the logger is nil. Successful calls are
+// logged at the debug level and failed calls at the error level, with the
+// method, its arguments and results, the duration and the error if any.
+func LogMiddleware(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return Middleware{
+		After: func(call *Call) {
+			attrs := make([]slog.Attr, 0, 4)
+			attrs = append(attrs, slog.String("method", string(call.Method)))
+
+			switch call.Method {
+			case MethodNext:
+				if call.Err == nil {
+					attrs = append(attrs, slog.Uint64("value", call.Value))
+				}
+			case MethodReserve:
+				attrs = append(attrs, slog.Uint64("n", call.Arg))
+			case MethodUpdate:
+				attrs = append(attrs, slog.Uint64("value", call.Arg))
+			}
+
+			attrs = append(attrs, slog.Duration("duration", time.Since(call.Start)))
+
+			level := slog.LevelDebug
+			if call.Err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", call.Err.Error()))
+			}
+
+			logger.LogAttrs(call.Context, level, "sequence call", attrs...)
+		},
+	}
+}
@@ -0,0 +1,255 @@
+package sequence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ensure that the chained sequence implements the sequence interfaces.
+func TestInterfaceChained(t *testing.T) {
+	var _ Incrementer = &ChainedSequence{}
+	var _ ContextIncrementer = &ChainedSequence{}
+}
+
+// Returns middleware that appends the name and method of each hook to calls.
+func traceMiddleware(name string, calls *[]string) Middleware {
+	return Middleware{
+		Before: func(call *Call) error {
+			*calls = append(*calls, "before "+name+" "+string(call.Method))
+			return nil
+		},
+		After: func(call *Call) {
+			*calls = append(*calls, "after "+name+" "+string(call.Method))
+		},
+	}
+}
+
+// Test that the hooks run around each intercepted method in order.
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	base, _ := New(10)
+	seq := Chain(base, traceMiddleware("a", &calls), Middleware{}, traceMiddleware("b", &calls))
+
+	if val, err := seq.Next(); err != nil || val != 1 {
+		t.Fatalf("expected 1 got %d (%v)", val, err)
+	}
+
+	expected := []string{"before a next", "before b next", "after b next", "after a next"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected hooks %v got %v", expected, calls)
+	}
+
+	calls = nil
+	seq.Update(5)
+	seq.Restart()
+	data, _ := seq.Dump()
+	seq.ReserveContext(context.Background(), 2)
+	Chain(new(Sequence), traceMiddleware("a", &calls)).Load(data)
+
+	expected = []string{
+		"before a update", "before b update", "after b update", "after a update",
+		"before a restart", "before b restart", "after b restart", "after a restart",
+		"before a dump", "before b dump", "after b dump", "after a dump",
+		"before a reserve", "before b reserve", "after b reserve", "after a reserve",
+		"before a load", "after a load",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected hooks %v got %v", expected, calls)
+	}
+
+	// Methods that do not modify the sequence are not intercepted.
+	calls = nil
+	seq.Current()
+	seq.IsStarted()
+	_ = seq.String()
+	if len(calls) != 0 {
+		t.Errorf("expected state methods to pass through, got %v", calls)
+	}
+}
+
+// Test that hooks see the arguments and results of the calls.
+func TestChainCall(t *testing.T) {
+	var results []Call
+	base, _ := New(2)
+	seq := Chain(base, Middleware{After: func(call *Call) { results = append(results, *call) }})
+
+	seq.Next()
+	seq.Next()
+	seq.Next()
+	seq.Update(1)
+	data, _ := seq.Dump()
+
+	if results[0].Value != 1 || results[1].Value != 2 || results[0].Err != nil {
+		t.Errorf("unexpected results of next %+v %+v", results[0], results[1])
+	}
+
+	if !errors.Is(results[2].Err, ErrExhausted) {
+		t.Errorf("expected exhausted error got %v", results[2].Err)
+	}
+
+	if results[3].Method != MethodUpdate || results[3].Arg != 1 || results[3].Err == nil {
+		t.Errorf("unexpected result of update %+v", results[3])
+	}
+
+	if !bytes.Equal(results[4].Data, data) || results[4].Start.IsZero() {
+		t.Errorf("unexpected result of dump %+v", results[4])
+	}
+}
+
+// Test that middleware can inject faults and replace errors.
+func TestChainFaults(t *testing.T) {
+	var calls []string
+	fault := errors.New("injected fault")
+
+	base, _ := New(10)
+	seq := Chain(base,
+		traceMiddleware("a", &calls),
+		Middleware{Before: func(call *Call) error {
+			if call.Method == MethodNext {
+				return fault
+			}
+			return nil
+		}},
+		traceMiddleware("b", &calls),
+	)
+
+	if _, err := seq.Next(); !errors.Is(err, fault) {
+		t.Errorf("expected injected fault got %v", err)
+	}
+
+	// The call is not made and only the middleware before the fault is called.
+	expected := []string{"before a next", "after a next"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected hooks %v got %v", expected, calls)
+	}
+
+	if base.IsStarted() {
+		t.Error("aborted call modified the sequence")
+	}
+
+	// After hooks may suppress errors.
+	seq = Chain(base, Middleware{After: func(call *Call) { call.Err = nil }})
+	if err := seq.Update(20); err != nil {
+		t.Errorf("expected error to be suppressed got %s", err)
+	}
+}
+
+// Test that Before hooks may rewrite the arguments of a call.
+func TestChainRewrite(t *testing.T) {
+	base, _ := New(100)
+	seq := Chain(base, Middleware{Before: func(call *Call) error {
+		switch call.Method {
+		case MethodUpdate:
+			call.Arg = 50
+		case MethodReserve:
+			call.Arg = 2
+		}
+		return nil
+	}})
+
+	if err := seq.Update(10); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Current(); val != 50 {
+		t.Errorf("expected rewritten update to 50 got %d", val)
+	}
+
+	if vals, err := seq.ReserveContext(context.Background(), 10); err != nil || len(vals) != 2 {
+		t.Errorf("expected rewritten reservation of 2 values got %v (%v)", vals, err)
+	}
+
+	data := []byte(`{"current":7,"increment":1,"minvalue":1,"maxvalue":10}`)
+	loaded := Chain(new(Sequence), Middleware{Before: func(call *Call) error {
+		call.Data = data
+		return nil
+	}})
+
+	if err := loaded.Load([]byte(`{}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := loaded.Current(); val != 7 {
+		t.Errorf("expected rewritten load at 7 got %d", val)
+	}
+}
+
+// Test that Next does not change the semantics of the wrapped sequence, e.g.
+// a non-blocking rate limited sequence does not block.
+func TestChainNextRateLimited(t *testing.T) {
+	base, _ := New()
+	limited, _ := NewRateLimited(base, 1, time.Hour)
+	limited.SetClock(newMockClock())
+	seq := Chain(limited)
+
+	if _, err := seq.Next(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := seq.Next(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited got %v", err)
+	}
+}
+
+// Test that the context of the call is passed to the hooks.
+func TestChainContext(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+
+	var seen []any
+	seq := Chain(new(Sequence), Middleware{Before: func(call *Call) error {
+		seen = append(seen, call.Context.Value(key{}))
+		return nil
+	}})
+	seq.Init()
+
+	if _, err := seq.NextContext(ctx); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cancel()
+	if _, err := seq.ReserveContext(ctx, 2); !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected canceled error got %v", err)
+	}
+
+	if len(seen) != 2 || seen[0] != "value" || seen[1] != "value" {
+		t.Errorf("hooks did not see the context of the calls: %v", seen)
+	}
+}
+
+// Test that calls are logged with their results.
+func TestLogMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	base, _ := New(1)
+	seq := Chain(base, LogMiddleware(logger))
+	seq.Next()
+	seq.Next()
+	seq.Update(1)
+
+	expected := []string{
+		`level=DEBUG msg="sequence call" method=next value=1`,
+		`level=ERROR msg="sequence call" method=next error="sequence exhausted: reached maximum bound of sequence"`,
+		`level=DEBUG msg="sequence call" method=update value=1`,
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected log lines\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,248 @@
+package sequence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// The number of Feistel rounds applied by a Permutation.
+const feistelRounds = 8
+
+// Permutation is a keyed bijection of the integer range [minvalue, maxvalue]
+// onto itself. It is used to hide the sequential nature of sequence values,
+// e.g. when they are exposed in URLs, without giving up uniqueness: every
+// value in the range maps to exactly one scrambled value in the same range
+// and can be recovered from it with Invert.
+//
+// The permutation is a balanced Feistel network whose round function is
+// AES keyed by the SHA-256 hash of the key. The network operates on the
+// smallest even number of bits that covers the range, and values that fall
+// outside the range are encrypted again (cycle-walking) until they fall
+// inside it, so the permutation is exactly onto the range however large or
+// small it is. Without the key, the ordering of scrambled values reveals
+// nothing about the order in which they were issued, though as with any
+// format-preserving scheme very small ranges can simply be enumerated.
+//
+// A Permutation is safe for concurrent use.
+type Permutation struct {
+	minvalue uint64       // The smallest value of the range
+	size     uint64       // The number of values in the range
+	half     uint         // The number of bits in each half of the network
+	block    cipher.Block // The keyed round function
+}
+
+// NewPermutation creates a permutation of [minvalue, maxvalue] keyed by the
+// key, which must not be empty. The same key and range always produce the
+// same permutation, so the key must be kept secret and stable for as long as
+// scrambled values must be inverted. The range must fit within the range of
+// a Sequence, i.e. maxvalue must not be greater than MaximumBound.
+func NewPermutation(key []byte, minvalue, maxvalue uint64) (*Permutation, error) {
+	if len(key) == 0 {
+		return nil, errors.New("permutation key cannot be empty")
+	}
+
+	if minvalue > maxvalue {
+		return nil, errors.New("the maximum value must be greater than or equal to the minimum value")
+	}
+
+	if maxvalue > MaximumBound {
+		return nil, errors.New("the maximum value cannot be greater than the maximum bound")
+	}
+
+	hash := sha256.Sum256(key)
+	block, err := aes.NewCipher(hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the smallest even number of bits, at least two, that can represent
+	// every offset into the range.
+	size := maxvalue - minvalue + 1
+	width := uint(bits.Len64(size - 1))
+	if width < 2 {
+		width = 2
+	}
+	width += width % 2
+
+	return &Permutation{minvalue: minvalue, size: size, half: width / 2, block: block}, nil
+}
+
+// Range returns the minimum and maximum value of the permuted range.
+func (p *Permutation) Range() (minvalue, maxvalue uint64) {
+	return p.minvalue, p.minvalue + p.size - 1
+}
+
+// Permute returns the scrambled value of val, which must be in the range.
+func (p *Permutation) Permute(val uint64) (uint64, error) {
+	if err := p.check(val); err != nil {
+		return 0, err
+	}
+
+	x := val - p.minvalue
+	for {
+		x = p.encrypt(x)
+		if x < p.size {
+			return x + p.minvalue, nil
+		}
+	}
+}
+
+// Invert returns the value whose scrambled value is val, which must be in the
+// range, such that Invert(Permute(v)) == v.
+func (p *Permutation) Invert(val uint64) (uint64, error) {
+	if err := p.check(val); err != nil {
+		return 0, err
+	}
+
+	x := val - p.minvalue
+	for {
+		x = p.decrypt(x)
+		if x < p.size {
+			return x + p.minvalue, nil
+		}
+	}
+}
+
+// Check that the value is in the range.
+func (p *Permutation) check(val uint64) error {
+	if val < p.minvalue || val-p.minvalue >= p.size {
+		minvalue, maxvalue := p.Range()
+		return fmt.Errorf("value %d is outside the permuted range %d to %d", val, minvalue, maxvalue)
+	}
+	return nil
+}
+
+// Apply the Feistel network to an offset into the range.
+func (p *Permutation) encrypt(x uint64) uint64 {
+	mask := uint64(1)<<p.half - 1
+	left, right := x>>p.half, x&mask
+	for round := 0; round < feistelRounds; round++ {
+		left, right = right, left^(p.round(round, right)&mask)
+	}
+	return left<<p.half | right
+}
+
+// Apply the Feistel network in reverse.
+func (p *Permutation) decrypt(x uint64) uint64 {
+	mask := uint64(1)<<p.half - 1
+	left, right := x>>p.half, x&mask
+	for round := feistelRounds - 1; round >= 0; round-- {
+		left, right = right^(p.round(round, left)&mask), left
+	}
+	return left<<p.half | right
+}
+
+// The round function: the encryption of the round number, the width of the
+// network and the half of the value being mixed.
+func (p *Permutation) round(round int, half uint64) uint64 {
+	var in, out [aes.BlockSize]byte
+	in[0] = byte(round)
+	in[1] = byte(p.half)
+	binary.BigEndian.PutUint64(in[8:], half)
+	p.block.Encrypt(out[:], in[:])
+	return binary.BigEndian.Uint64(out[:8])
+}
+
+//===========================================================================
+// Scrambled Sequences
+//===========================================================================
+
+// ScrambledSequence wraps a bounded Incrementer such as a Sequence or
+// AtomicSequence, scrambling the values that it issues with a keyed
+// Permutation. Only the values that the sequence can issue are permuted: the
+// index of each value, (val-minvalue)/increment, is permuted and mapped back
+// onto the step of the sequence, so every scrambled value is a unique value
+// that the sequence could have issued. Scrambled values can be recovered
+// with Invert.
+//
+// The range and step of the sequence are read when it is wrapped, so it must
+// not be altered afterwards. A ScrambledSequence is as safe for concurrent
+// use as the Incrementer it wraps.
+type ScrambledSequence struct {
+	inc       Incrementer  // The sequence values are drawn from
+	perm      *Permutation // Maps indexes of values to scrambled indexes
+	minvalue  uint64       // The minimum value of the sequence
+	maxvalue  uint64       // The maximum value of the sequence
+	increment uint64       // The step between values of the sequence
+}
+
+// NewScrambled wraps an initialized Incrementer that implements Bounded,
+// scrambling its values with a permutation keyed by the key as described by
+// NewPermutation.
+func NewScrambled(inc Incrementer, key []byte) (*ScrambledSequence, error) {
+	bounded, ok := inc.(Bounded)
+	if !ok {
+		return nil, errors.New("cannot scramble a sequence that does not implement Bounded")
+	}
+
+	minvalue, maxvalue, increment := bounded.Bounds()
+	if increment == 0 {
+		return nil, errors.New("cannot scramble an uninitialized sequence")
+	}
+
+	perm, err := NewPermutation(key, 0, (maxvalue-minvalue)/increment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScrambledSequence{inc: inc, perm: perm, minvalue: minvalue, maxvalue: maxvalue, increment: increment}, nil
+}
+
+// Next returns the scrambled next value of the sequence.
+func (s *ScrambledSequence) Next() (uint64, error) {
+	val, err := s.inc.Next()
+	if err != nil {
+		return 0, err
+	}
+	return s.Permute(val)
+}
+
+// Current returns the scrambled current value of the sequence.
+func (s *ScrambledSequence) Current() (uint64, error) {
+	val, err := s.inc.Current()
+	if err != nil {
+		return 0, err
+	}
+	return s.Permute(val)
+}
+
+// Permute returns the scrambled value of val, which must be a value that the
+// sequence can issue.
+func (s *ScrambledSequence) Permute(val uint64) (uint64, error) {
+	idx, err := s.index(val)
+	if err != nil {
+		return 0, err
+	}
+
+	if idx, err = s.perm.Permute(idx); err != nil {
+		return 0, err
+	}
+	return s.minvalue + idx*s.increment, nil
+}
+
+// Invert returns the value of the sequence whose scrambled value is val,
+// such that Invert(Permute(v)) == v.
+func (s *ScrambledSequence) Invert(val uint64) (uint64, error) {
+	idx, err := s.index(val)
+	if err != nil {
+		return 0, err
+	}
+
+	if idx, err = s.perm.Invert(idx); err != nil {
+		return 0, err
+	}
+	return s.minvalue + idx*s.increment, nil
+}
+
+// Returns the index of a value of the sequence.
+func (s *ScrambledSequence) index(val uint64) (uint64, error) {
+	if val < s.minvalue || val > s.maxvalue || (val-s.minvalue)%s.increment != 0 {
+		return 0, fmt.Errorf("value %d is not a value of the sequence incremented by %d between %d and %d", val, s.increment, s.minvalue, s.maxvalue)
+	}
+	return (val - s.minvalue) / s.increment, nil
+}
@@ -0,0 +1,219 @@
+package sequence
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test that permutations of small ranges are bijections onto the range.
+func TestPermutationBijection(t *testing.T) {
+	ranges := [][2]uint64{{1, 1}, {1, 2}, {5, 7}, {10, 17}, {1, 1000}, {1 << 20, 1<<20 + 4095}}
+
+	for _, r := range ranges {
+		p, err := NewPermutation([]byte("secret"), r[0], r[1])
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if minvalue, maxvalue := p.Range(); minvalue != r[0] || maxvalue != r[1] {
+			t.Errorf("expected range %d to %d got %d to %d", r[0], r[1], minvalue, maxvalue)
+		}
+
+		seen := make(map[uint64]bool)
+		unchanged := 0
+		for val := r[0]; val <= r[1]; val++ {
+			scrambled, err := p.Permute(val)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if scrambled < r[0] || scrambled > r[1] {
+				t.Fatalf("%d was permuted outside of the range to %d", val, scrambled)
+			}
+
+			if seen[scrambled] {
+				t.Fatalf("%d was permuted to duplicate value %d", val, scrambled)
+			}
+			seen[scrambled] = true
+
+			if scrambled == val {
+				unchanged++
+			}
+
+			if inverted, err := p.Invert(scrambled); err != nil || inverted != val {
+				t.Fatalf("expected %d to invert to %d got %d (%v)", scrambled, val, inverted, err)
+			}
+		}
+
+		if size := r[1] - r[0] + 1; size >= 1000 && unchanged > int(size/100) {
+			t.Errorf("%d of %d values were not scrambled", unchanged, size)
+		}
+	}
+}
+
+// Test permuting and inverting values of the full sequence range.
+func TestPermutationFullRange(t *testing.T) {
+	p, err := NewPermutation([]byte("secret"), MinimumBound, MaximumBound)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, val := range []uint64{MinimumBound, 2, 3, 1 << 32, 1<<63 + 7, MaximumBound - 1, MaximumBound} {
+		scrambled, err := p.Permute(val)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if scrambled < MinimumBound || scrambled > MaximumBound {
+			t.Errorf("%d was permuted outside of the range to %d", val, scrambled)
+		}
+
+		if inverted, err := p.Invert(scrambled); err != nil || inverted != val {
+			t.Errorf("expected %d to invert to %d got %d (%v)", scrambled, val, inverted, err)
+		}
+	}
+
+	// Consecutive values should not be scrambled to nearby values.
+	a, _ := p.Permute(1)
+	b, _ := p.Permute(2)
+	if diff := int64(a - b); diff > -1<<32 && diff < 1<<32 {
+		t.Errorf("consecutive values were permuted to nearby values %d and %d", a, b)
+	}
+}
+
+// Test that permutations are determined by the key.
+func TestPermutationKey(t *testing.T) {
+	p1, _ := NewPermutation([]byte("secret"), 1, 1000000)
+	p2, _ := NewPermutation([]byte("secret"), 1, 1000000)
+	p3, _ := NewPermutation([]byte("another secret"), 1, 1000000)
+
+	same, different := 0, 0
+	for val := uint64(1); val <= 100; val++ {
+		v1, _ := p1.Permute(val)
+		v2, _ := p2.Permute(val)
+		v3, _ := p3.Permute(val)
+
+		if v1 == v2 {
+			same++
+		}
+
+		if v1 != v3 {
+			different++
+		}
+	}
+
+	if same != 100 {
+		t.Errorf("the same key produced different permutations")
+	}
+
+	if different < 95 {
+		t.Errorf("different keys produced similar permutations")
+	}
+}
+
+// Test permutation errors.
+func TestPermutationErrors(t *testing.T) {
+	if _, err := NewPermutation(nil, 1, 10); err == nil {
+		t.Error("created a permutation without a key")
+	}
+
+	if _, err := NewPermutation([]byte("secret"), 10, 1); err == nil {
+		t.Error("created a permutation with an empty range")
+	}
+
+	if _, err := NewPermutation([]byte("secret"), 0, MaximumBound+1); err == nil {
+		t.Error("created a permutation beyond the maximum bound")
+	}
+
+	p, _ := NewPermutation([]byte("secret"), 10, 20)
+	for _, val := range []uint64{0, 9, 21, MaximumBound} {
+		if _, err := p.Permute(val); err == nil {
+			t.Errorf("permuted %d outside of the range", val)
+		}
+
+		if _, err := p.Invert(val); err == nil {
+			t.Errorf("inverted %d outside of the range", val)
+		}
+	}
+}
+
+// Test that a scrambled sequence issues every value of the sequence once,
+// respecting its step, and that the values can be inverted.
+func TestScrambled(t *testing.T) {
+	base, _ := New(2, 100, 2)
+	seq, err := NewScrambled(base, []byte("secret"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	seen := make(map[uint64]bool)
+	unchanged := 0
+	for i := uint64(1); ; i++ {
+		val, err := seq.Next()
+		if err != nil {
+			if !errors.Is(err, ErrExhausted) {
+				t.Fatal(err.Error())
+			}
+			break
+		}
+
+		if val < 2 || val > 100 || val%2 != 0 {
+			t.Fatalf("scrambled value %d is not a value of the sequence", val)
+		}
+
+		if seen[val] {
+			t.Fatalf("scrambled value %d was issued twice", val)
+		}
+		seen[val] = true
+
+		if val == 2*i {
+			unchanged++
+		}
+
+		if current, err := seq.Current(); err != nil || current != val {
+			t.Errorf("expected current value %d got %d (%v)", val, current, err)
+		}
+
+		if original, err := seq.Invert(val); err != nil || original != 2*i {
+			t.Fatalf("expected %d to invert to %d got %d (%v)", val, 2*i, original, err)
+		}
+	}
+
+	if len(seen) != 50 {
+		t.Errorf("expected 50 scrambled values got %d", len(seen))
+	}
+
+	if unchanged > 5 {
+		t.Errorf("%d of 50 values were not scrambled", unchanged)
+	}
+
+	// Values that the sequence cannot issue are rejected.
+	for _, val := range []uint64{0, 3, 102} {
+		if _, err := seq.Permute(val); err == nil {
+			t.Errorf("permuted %d which is not a value of the sequence", val)
+		}
+
+		if _, err := seq.Invert(val); err == nil {
+			t.Errorf("inverted %d which is not a value of the sequence", val)
+		}
+	}
+
+	if _, err := NewScrambled(&plainIncrementer{base}, []byte("secret")); err == nil {
+		t.Error("scrambled a sequence without bounds")
+	}
+
+	if _, err := NewScrambled(new(Sequence), []byte("secret")); err == nil {
+		t.Error("scrambled an uninitialized sequence")
+	}
+}
+
+//===========================================================================
+// Benchmarks
+//===========================================================================
+
+func BenchmarkPermute(b *testing.B) {
+	p, _ := NewPermutation([]byte("secret"), MinimumBound, MaximumBound)
+	for i := 0; i < b.N; i++ {
+		p.Permute(uint64(i) + 1)
+	}
+}
@@ -0,0 +1,266 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by a non-blocking RateLimitedSequence when no
+// more values can be issued in the current window.
+var ErrRateLimited = errors.New("sequence rate limit exceeded")
+
+// Clock is the source of time for sequences that depend on the passage of
+// time. It is primarily used to inject deterministic clocks in tests.
+type Clock interface {
+	Now() time.Time                         // Returns the current time
+	After(d time.Duration) <-chan time.Time // Returns a channel that fires after d
+}
+
+// The default Clock uses the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RateLimitedSequence wraps any Incrementer, capping the number of values
+// issued to limit values per window using token-bucket semantics: the bucket
+// starts full with limit tokens, each value consumes one token, and tokens
+// are refilled continuously at a rate of limit per window. Short bursts of up
+// to limit values are therefore allowed, but the long term rate is capped.
+//
+// By default Next does not block and returns ErrRateLimited if no token is
+// available. NextContext and ReserveContext always block until enough tokens
+// are available or the context is done; SetBlocking(true) makes Next block
+// in the same way. A RateLimitedSequence is safe for concurrent use, though
+// the wrapped Incrementer should not be modified directly once wrapped.
+type RateLimitedSequence struct {
+	mu       sync.Mutex
+	inc      Incrementer   // The underlying sequence values are drawn from
+	limit    uint64        // The maximum number of values issued per window
+	window   time.Duration // The period over which the limit is enforced
+	tokens   float64       // The number of values that may currently be issued
+	last     time.Time     // The last time the tokens were refilled
+	blocking bool          // If true, Next waits for a token rather than erroring
+	clock    Clock         // The source of time for refilling tokens
+}
+
+// NewRateLimited wraps the Incrementer so that at most limit values are
+// issued per window. The Incrementer should already be initialized.
+func NewRateLimited(inc Incrementer, limit uint64, window time.Duration) (*RateLimitedSequence, error) {
+	if inc == nil {
+		return nil, errors.New("cannot rate limit a nil sequence")
+	}
+
+	if limit == 0 || window <= 0 {
+		return nil, errors.New("rate limit must allow at least one value per positive window")
+	}
+
+	clock := systemClock{}
+	return &RateLimitedSequence{
+		inc:    inc,
+		limit:  limit,
+		window: window,
+		tokens: float64(limit),
+		last:   clock.Now(),
+		clock:  clock,
+	}, nil
+}
+
+// SetBlocking specifies whether Next waits for a token to become available
+// (true) or returns ErrRateLimited immediately (false, the default).
+func (s *RateLimitedSequence) SetBlocking(blocking bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocking = blocking
+}
+
+// SetClock replaces the source of time of the rate limiter, resetting the
+// bucket to full. This is primarily used to inject a clock for testing.
+func (s *RateLimitedSequence) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+	s.tokens = float64(s.limit)
+	s.last = clock.Now()
+}
+
+//===========================================================================
+// Rate Limited Interaction Methods
+//===========================================================================
+
+// Next returns the next value of the underlying sequence if the rate limit
+// allows it. In non-blocking mode ErrRateLimited is returned if no token is
+// available, otherwise Next waits for a token.
+func (s *RateLimitedSequence) Next() (uint64, error) {
+	s.mu.Lock()
+	blocking := s.blocking
+	s.mu.Unlock()
+
+	if blocking {
+		return s.NextContext(context.Background())
+	}
+
+	vals, wait, err := s.take(1)
+	if err != nil {
+		return 0, err
+	}
+
+	if wait > 0 {
+		return 0, ErrRateLimited
+	}
+	return vals[0], nil
+}
+
+// NextContext waits until the rate limit allows a value to be issued and
+// then returns the next value of the underlying sequence, unless the context
+// is done first.
+func (s *RateLimitedSequence) NextContext(ctx context.Context) (uint64, error) {
+	vals, err := s.ReserveContext(ctx, 1)
+	if err != nil {
+		return 0, err
+	}
+	return vals[0], nil
+}
+
+// ReserveContext waits until the rate limit allows n values to be issued and
+// then reserves n values from the underlying sequence, unless the context is
+// done first. An error is returned if n is greater than the limit since the
+// reservation could never be satisfied.
+func (s *RateLimitedSequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	if n > s.limit {
+		return nil, fmt.Errorf("cannot reserve %d values with a rate limit of %d", n, s.limit)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, WrapContextError(err)
+		}
+
+		vals, wait, err := s.take(n)
+		if err != nil || wait == 0 {
+			return vals, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, WrapContextError(ctx.Err())
+		case <-s.after(wait):
+		}
+	}
+}
+
+// Take n tokens and values from the underlying sequence if enough tokens are
+// available, otherwise return the time to wait until they will be available.
+// Tokens are returned to the bucket if the underlying sequence errors.
+func (s *RateLimitedSequence) take(n uint64) (vals []uint64, wait time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refill()
+	if need := float64(n) - s.tokens; need > 0 {
+		wait = time.Duration(need * float64(s.window) / float64(s.limit))
+		if wait <= 0 {
+			wait = 1
+		}
+		return nil, wait, nil
+	}
+
+	if n == 1 {
+		var val uint64
+		if val, err = s.inc.Next(); err == nil {
+			vals = []uint64{val}
+		}
+	} else {
+		vals, err = ReserveContext(context.Background(), s.inc, n)
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.tokens -= float64(n)
+	return vals, 0, nil
+}
+
+// Refill the bucket based on the time elapsed. Must be called with the lock.
+func (s *RateLimitedSequence) refill() {
+	now := s.clock.Now()
+	if elapsed := now.Sub(s.last); elapsed > 0 {
+		s.tokens += float64(elapsed) * float64(s.limit) / float64(s.window)
+		if s.tokens > float64(s.limit) {
+			s.tokens = float64(s.limit)
+		}
+	}
+	s.last = now
+}
+
+// Returns a channel that fires after d according to the clock.
+func (s *RateLimitedSequence) after(d time.Duration) <-chan time.Time {
+	s.mu.Lock()
+	clock := s.clock
+	s.mu.Unlock()
+	return clock.After(d)
+}
+
+// Init initializes the underlying sequence.
+func (s *RateLimitedSequence) Init(params ...uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Init(params...)
+}
+
+// Restart the underlying sequence. The rate limit is not reset.
+func (s *RateLimitedSequence) Restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Restart()
+}
+
+// Update the underlying sequence. Updates do not consume tokens.
+func (s *RateLimitedSequence) Update(val uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Update(val)
+}
+
+//===========================================================================
+// Rate Limited State Methods
+//===========================================================================
+
+// Current returns the current value of the underlying sequence.
+func (s *RateLimitedSequence) Current() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Current()
+}
+
+// IsStarted returns the state of the underlying sequence.
+func (s *RateLimitedSequence) IsStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.IsStarted()
+}
+
+// String returns a human readable representation of the rate limited sequence.
+func (s *RateLimitedSequence) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("%s, rate limited to %d values per %s", s.inc, s.limit, s.window)
+}
+
+// Dump the underlying sequence. The state of the rate limiter is not dumped.
+func (s *RateLimitedSequence) Dump() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Dump()
+}
+
+// Load the underlying sequence.
+func (s *RateLimitedSequence) Load(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inc.Load(data)
+}
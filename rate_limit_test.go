@@ -0,0 +1,245 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+//===========================================================================
+// Mock Clock
+//===========================================================================
+
+// A deterministic Clock whose time only moves when it is advanced.
+type mockClock struct {
+	sync.Mutex
+	now     time.Time
+	waiters []mockWaiter
+	waiting chan struct{}
+}
+
+type mockWaiter struct {
+	until time.Time
+	c     chan time.Time
+}
+
+func newMockClock() *mockClock {
+	return &mockClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), waiting: make(chan struct{}, 16)}
+}
+
+func (c *mockClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *mockClock) After(d time.Duration) <-chan time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, mockWaiter{until: c.now.Add(d), c: ch})
+	c.waiting <- struct{}{}
+	return ch
+}
+
+// Advance the clock, firing any waiters whose time has come.
+func (c *mockClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.until.After(c.now) {
+			w.c <- c.now
+		} else {
+			waiters = append(waiters, w)
+		}
+	}
+	c.waiters = waiters
+}
+
+//===========================================================================
+// Rate Limited Sequence Tests
+//===========================================================================
+
+// Ensure that the rate limited sequence implements the sequence interfaces.
+func TestRateLimitedInterface(t *testing.T) {
+	var _ Incrementer = &RateLimitedSequence{}
+	var _ ContextIncrementer = &RateLimitedSequence{}
+}
+
+// Test that bad rate limits are rejected.
+func TestNewRateLimitedErrors(t *testing.T) {
+	seq, _ := New()
+	if _, err := NewRateLimited(nil, 10, time.Second); err == nil {
+		t.Error("rate limited a nil sequence")
+	}
+
+	if _, err := NewRateLimited(seq, 0, time.Second); err == nil {
+		t.Error("allowed a zero rate limit")
+	}
+
+	if _, err := NewRateLimited(seq, 10, 0); err == nil {
+		t.Error("allowed a zero window")
+	}
+}
+
+// Test the non-blocking mode and token refill.
+func TestRateLimitedNext(t *testing.T) {
+	seq, _ := New()
+	clock := newMockClock()
+
+	rl, err := NewRateLimited(seq, 5, time.Second)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rl.SetClock(clock)
+
+	// The bucket starts full so a burst of 5 values is allowed.
+	for i := uint64(1); i <= 5; i++ {
+		idx, err := rl.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx != i {
+			t.Fatalf("expected %d got %d", i, idx)
+		}
+	}
+
+	if _, err := rl.Next(); err != ErrRateLimited {
+		t.Fatalf("expected rate limit error got %v", err)
+	}
+
+	// Rate limited calls do not consume values from the sequence.
+	if idx, _ := rl.Current(); idx != 5 {
+		t.Errorf("rate limited call modified the sequence to %d", idx)
+	}
+
+	// A token is refilled every 200ms.
+	clock.Advance(199 * time.Millisecond)
+	if _, err := rl.Next(); err != ErrRateLimited {
+		t.Fatalf("expected rate limit error got %v", err)
+	}
+
+	clock.Advance(time.Millisecond)
+	if idx, err := rl.Next(); err != nil || idx != 6 {
+		t.Fatalf("expected 6 got %d (%v)", idx, err)
+	}
+
+	// The bucket never holds more than the limit.
+	clock.Advance(time.Hour)
+	for i := 0; i < 5; i++ {
+		if _, err := rl.Next(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if _, err := rl.Next(); err != ErrRateLimited {
+		t.Fatalf("expected rate limit error got %v", err)
+	}
+}
+
+// Test that tokens are returned when the underlying sequence errors.
+func TestRateLimitedExhausted(t *testing.T) {
+	seq, _ := New(2)
+	rl, _ := NewRateLimited(seq, 3, time.Minute)
+	rl.SetClock(newMockClock())
+
+	rl.Next()
+	rl.Next()
+
+	if _, err := rl.Next(); err == nil || err == ErrRateLimited {
+		t.Fatalf("expected exhausted sequence error got %v", err)
+	}
+
+	if rl.tokens != 1 {
+		t.Errorf("expected 1 token remaining got %f", rl.tokens)
+	}
+}
+
+// Test that NextContext waits for a token.
+func TestRateLimitedNextContext(t *testing.T) {
+	seq, _ := New()
+	clock := newMockClock()
+
+	rl, _ := NewRateLimited(seq, 2, time.Second)
+	rl.SetClock(clock)
+
+	vals, err := rl.ReserveContext(context.Background(), 2)
+	if err != nil || len(vals) != 2 {
+		t.Fatalf("could not reserve initial burst: %v", err)
+	}
+
+	result := make(chan uint64, 1)
+	go func() {
+		idx, err := rl.NextContext(context.Background())
+		if err != nil {
+			t.Error(err.Error())
+		}
+		result <- idx
+	}()
+
+	// Wait for the call to block on the clock before advancing it.
+	<-clock.waiting
+	select {
+	case <-result:
+		t.Fatal("NextContext did not wait for a token")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if idx := <-result; idx != 3 {
+		t.Errorf("expected 3 got %d", idx)
+	}
+}
+
+// Test that a blocking Next can be canceled by a context deadline.
+func TestRateLimitedCanceled(t *testing.T) {
+	seq, _ := New()
+	rl, _ := NewRateLimited(seq, 1, time.Hour)
+
+	if _, err := rl.Next(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := rl.NextContext(ctx); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded got %v", err)
+	}
+
+	if _, err := rl.ReserveContext(ctx, 2); err == nil {
+		t.Error("allowed a reservation larger than the limit")
+	}
+}
+
+// Test the blocking mode of Next.
+func TestRateLimitedBlocking(t *testing.T) {
+	seq, _ := New()
+	clock := newMockClock()
+
+	rl, _ := NewRateLimited(seq, 1, time.Second)
+	rl.SetClock(clock)
+	rl.SetBlocking(true)
+
+	rl.Next()
+
+	result := make(chan uint64, 1)
+	go func() {
+		idx, _ := rl.Next()
+		result <- idx
+	}()
+
+	<-clock.waiting
+	clock.Advance(time.Second)
+
+	if idx := <-result; idx != 2 {
+		t.Errorf("expected 2 got %d", idx)
+	}
+}
@@ -0,0 +1,186 @@
+// Package replicated provides a highly available sequence that is replicated
+// across a Raft group. Rather than agreeing on every single value, the Raft
+// log is used to agree on block allocations: the leader asks the group to
+// allocate a block of values from a shared sequence.Sequence, and then hands
+// out values from that block locally without further coordination. Because a
+// block is only ever handed to the node that committed it and the shared
+// sequence is monotonically increasing, a failover can skip the unused values
+// of a block but can never reissue a value.
+//
+// The FSM implements the raft.FSM interface and must be passed to
+// raft.NewRaft; the Sequence is then constructed from the resulting raft node:
+//
+//     fsm, err := replicated.NewFSM(1, 1000000)
+//     node, err := raft.NewRaft(conf, fsm, logs, stable, snaps, transport)
+//     seq := replicated.New(node, 1000, 5*time.Second)
+//     idx, err := seq.Next()
+//
+// Snapshots of the FSM are in the Sequence Dump format so that the state of
+// the replicated sequence can be inspected and loaded by a plain Sequence.
+package replicated
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sync"
+
+	"github.com/bbengfort/sequence"
+	"github.com/hashicorp/raft"
+)
+
+// Block is a contiguous allocation of values from the replicated sequence.
+// Values are handed out from First to Last (inclusive) by the step of the
+// underlying sequence.
+type Block struct {
+	First uint64 // The first value in the block
+	Last  uint64 // The last value in the block
+	Step  uint64 // The step between values in the block
+}
+
+// command is the JSON encoded entry that is appended to the Raft log.
+type command struct {
+	Op   string `json:"op"`
+	Size uint64 `json:"size"`
+}
+
+// The only operation currently understood by the FSM.
+const opAllocate = "allocate"
+
+// FSM is the Raft finite state machine that owns the shared sequence. Every
+// member of the Raft group applies the same allocations in the same order, so
+// every member agrees on the high-water mark of the sequence.
+type FSM struct {
+	mu  sync.Mutex         // Guards the state of the FSM
+	seq *sequence.Sequence // The shared sequence allocations are drawn from
+}
+
+// NewFSM creates a state machine for a replicated sequence. The params are
+// interpreted exactly as they are by sequence.New and every member of the
+// Raft group must be created with the same params.
+func NewFSM(params ...uint64) (*FSM, error) {
+	seq, err := sequence.New(params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FSM{seq: seq}, nil
+}
+
+// Apply a committed Raft log entry to the state machine. Allocate entries
+// return a *Block on success or an error if the sequence is exhausted or the
+// entry cannot be decoded.
+func (f *FSM) Apply(log *raft.Log) interface{} {
+	var cmd command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		return fmt.Errorf("could not decode log entry: %s", err)
+	}
+
+	switch cmd.Op {
+	case opAllocate:
+		return f.allocate(cmd.Size)
+	default:
+		return fmt.Errorf("unknown replicated sequence operation %q", cmd.Op)
+	}
+}
+
+// Allocate a block of at most size values from the shared sequence. If fewer
+// than size values remain, the partial block is returned; an error is
+// returned only if no values could be allocated. The block is allocated with
+// a single update of the sequence, so the cost does not depend on its size.
+func (f *FSM) allocate(size uint64) interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if size == 0 {
+		return errors.New("cannot allocate an empty block")
+	}
+
+	n := min(size, f.seq.Remaining())
+	if n == 0 {
+		return ErrExhausted
+	}
+
+	// The first value follows the current value unless none have been issued.
+	minvalue, _, step := f.seq.Bounds()
+	first := minvalue
+	if current, err := f.seq.Current(); err == nil {
+		first = current + step
+	}
+
+	// Compute first + (n-1)*step, which cannot exceed the maximum value
+	// since n values remain, but is checked to guard against corruption.
+	hi, span := bits.Mul64(n-1, step)
+	last, carry := bits.Add64(first, span, 0)
+	if hi != 0 || carry != 0 {
+		return fmt.Errorf("cannot allocate %d values after %d: block overflows", n, first)
+	}
+
+	if err := f.seq.Update(last); err != nil {
+		return err
+	}
+	return &Block{First: first, Last: last, Step: step}
+}
+
+// Snapshot returns a point in time snapshot of the shared sequence.
+func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := f.seq.Dump()
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot(data), nil
+}
+
+// Restore the state machine from a snapshot, discarding the current state.
+func (f *FSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	seq := new(sequence.Sequence)
+	if err := seq.Load(data); err != nil {
+		return fmt.Errorf("could not decode snapshot: %s", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq = seq
+	return nil
+}
+
+// String returns a human readable representation of the shared sequence.
+func (f *FSM) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq.String()
+}
+
+//===========================================================================
+// FSM Snapshots
+//===========================================================================
+
+// snapshot is the shared sequence in the Sequence Dump format, which records
+// whether it is unstarted or exhausted, so no other state is needed.
+type snapshot []byte
+
+// Persist writes the snapshot to the given sink.
+func (s snapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+// Release is a no-op since the snapshot holds no resources.
+func (s snapshot) Release() {}
@@ -0,0 +1,213 @@
+package replicated
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/bbengfort/sequence"
+	"github.com/hashicorp/raft"
+)
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// Apply an allocate command of the given size directly to the FSM.
+func applyAllocate(t *testing.T, fsm *FSM, size uint64) interface{} {
+	data, err := json.Marshal(command{Op: opAllocate, Size: size})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return fsm.Apply(&raft.Log{Data: data})
+}
+
+// A snapshot sink that writes to an in-memory buffer.
+type bufferSink struct {
+	bytes.Buffer
+	canceled bool
+}
+
+func (s *bufferSink) ID() string    { return "buffer" }
+func (s *bufferSink) Close() error  { return nil }
+func (s *bufferSink) Cancel() error { s.canceled = true; return nil }
+
+// Snapshot the FSM and return the persisted bytes.
+func persist(t *testing.T, fsm *FSM) []byte {
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer snap.Release()
+
+	sink := new(bufferSink)
+	if err := snap.Persist(sink); err != nil {
+		t.Fatal(err.Error())
+	}
+	return sink.Bytes()
+}
+
+//===========================================================================
+// FSM Tests
+//===========================================================================
+
+// Test that allocations are contiguous and never overlap.
+func TestFSMAllocate(t *testing.T) {
+	fsm, err := NewFSM(2, 100, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	first, ok := applyAllocate(t, fsm, 10).(*Block)
+	if !ok {
+		t.Fatal("allocation did not return a block")
+	}
+
+	if first.First != 2 || first.Last != 20 || first.Step != 2 {
+		t.Errorf("unexpected first block %+v", first)
+	}
+
+	second, ok := applyAllocate(t, fsm, 10).(*Block)
+	if !ok {
+		t.Fatal("allocation did not return a block")
+	}
+
+	if second.First != 22 || second.Last != 40 {
+		t.Errorf("unexpected second block %+v", second)
+	}
+}
+
+// Test that the final block is partial and subsequent allocations fail.
+func TestFSMExhausted(t *testing.T) {
+	fsm, err := NewFSM(25)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, ok := applyAllocate(t, fsm, 10).(*Block); !ok {
+			t.Fatal("allocation did not return a block")
+		}
+	}
+
+	last, ok := applyAllocate(t, fsm, 10).(*Block)
+	if !ok {
+		t.Fatal("allocation did not return a block")
+	}
+
+	if last.First != 21 || last.Last != 25 {
+		t.Errorf("unexpected partial block %+v", last)
+	}
+
+	if err, ok := applyAllocate(t, fsm, 10).(error); !ok || err != ErrExhausted {
+		t.Error("expected an exhausted error after the final block")
+	}
+}
+
+// Test that large blocks are allocated without stepping through each value.
+func TestFSMAllocateLarge(t *testing.T) {
+	fsm, err := NewFSM(5, sequence.MaximumBound, 5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	block, ok := applyAllocate(t, fsm, sequence.MaximumBound).(*Block)
+	if !ok {
+		t.Fatal("allocation did not return a block")
+	}
+
+	if block.First != 5 || block.Last != 18446744073709551610 || block.Step != 5 {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	if err, ok := applyAllocate(t, fsm, 1).(error); !ok || err != ErrExhausted {
+		t.Error("expected an exhausted error after the final block")
+	}
+}
+
+// Test that bad commands return errors rather than panicking.
+func TestFSMBadCommand(t *testing.T) {
+	fsm, err := NewFSM()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, ok := fsm.Apply(&raft.Log{Data: []byte("foo")}).(error); !ok {
+		t.Error("expected an error for an undecodable command")
+	}
+
+	if _, ok := fsm.Apply(&raft.Log{Data: []byte(`{"op":"restart"}`)}).(error); !ok {
+		t.Error("expected an error for an unknown command")
+	}
+
+	if _, ok := applyAllocate(t, fsm, 0).(error); !ok {
+		t.Error("expected an error for an empty allocation")
+	}
+}
+
+// Test that snapshots use the Sequence Dump format and restore the state.
+func TestFSMSnapshotRestore(t *testing.T) {
+	fsm, err := NewFSM(1000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	applyAllocate(t, fsm, 100)
+	data := persist(t, fsm)
+
+	// The snapshot can be loaded by a plain Sequence.
+	seq := new(sequence.Sequence)
+	if err := seq.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if idx, _ := seq.Current(); idx != 100 {
+		t.Errorf("snapshot sequence is at %d not 100", idx)
+	}
+
+	// Allocate more values then restore to the snapshot.
+	applyAllocate(t, fsm, 100)
+	if err := fsm.Restore(io.NopCloser(bytes.NewReader(data))); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	block, ok := applyAllocate(t, fsm, 10).(*Block)
+	if !ok {
+		t.Fatal("allocation did not return a block")
+	}
+
+	if block.First != 101 {
+		t.Errorf("restored fsm allocated from %d not 101", block.First)
+	}
+}
+
+// Test that unstarted and exhausted sequences survive a snapshot.
+func TestFSMSnapshotStates(t *testing.T) {
+	fsm, err := NewFSM(10)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// An unstarted sequence restores to an unstarted sequence.
+	data := persist(t, fsm)
+	if err := fsm.Restore(io.NopCloser(bytes.NewReader(data))); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	block, ok := applyAllocate(t, fsm, 10).(*Block)
+	if !ok || block.First != 1 || block.Last != 10 {
+		t.Fatalf("unexpected block after restoring unstarted fsm: %+v", block)
+	}
+
+	// A sequence at its maximum value must never be restarted.
+	data = persist(t, fsm)
+	restored, _ := NewFSM(10)
+	if err := restored.Restore(io.NopCloser(bytes.NewReader(data))); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err, ok := applyAllocate(t, restored, 10).(error); !ok || err != ErrExhausted {
+		t.Error("restored exhausted fsm allocated values")
+	}
+}
@@ -0,0 +1,193 @@
+package replicated
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bbengfort/sequence"
+	"github.com/hashicorp/raft"
+)
+
+// ErrNotLeader is returned when a value is requested from a member of the
+// Raft group that is not the leader. Callers should retry on the leader.
+var ErrNotLeader = errors.New("replicated sequence is not the raft leader")
+
+// ErrExhausted is returned when the replicated sequence has no more values to
+// allocate to the leader. It wraps sequence.ErrExhausted.
+var ErrExhausted = fmt.Errorf("%w: reached maximum bound of replicated sequence", sequence.ErrExhausted)
+
+// DefaultBlockSize is the number of values allocated by the leader at a time
+// if no block size is specified.
+const DefaultBlockSize = 1000
+
+// Sequence hands out values from blocks that are allocated by the Raft group.
+// Only the leader hands out values; when its current block is used up it
+// appends an allocation to the Raft log and waits for the allocation to be
+// committed and applied before handing out values from the new block. A
+// Sequence is safe for concurrent use.
+type Sequence struct {
+	mu        sync.Mutex
+	raft      *raft.Raft    // The raft node used to commit allocations
+	size      uint64        // The number of values to allocate per block
+	timeout   time.Duration // The maximum time to wait to enqueue an allocation
+	block     *Block        // The block values are currently being handed out from
+	current   uint64        // The last value handed out from the block
+	exhausted bool          // Set when the block has been used up
+}
+
+// New creates a replicated Sequence on the given raft node, which must have
+// been created with an FSM from NewFSM. The size is the number of values
+// allocated per block (DefaultBlockSize if zero) and timeout limits how long
+// Next waits to enqueue an allocation in the Raft log.
+func New(node *raft.Raft, size uint64, timeout time.Duration) *Sequence {
+	if size == 0 {
+		size = DefaultBlockSize
+	}
+	return &Sequence{raft: node, size: size, timeout: timeout, exhausted: true}
+}
+
+// Next returns the next value from the current block, allocating a new block
+// through the Raft group if the current block is used up. Values are unique
+// across the Raft group and are increasing for as long as a single node
+// remains the leader. Next returns ErrNotLeader if called on a follower.
+func (s *Sequence) Next() (uint64, error) {
+	return s.NextContext(context.Background())
+}
+
+// NextContext returns the next value as described by Next, but stops waiting
+// for a new block to be committed when the context is done. A block that is
+// committed after the context is done is discarded, so its values are
+// skipped rather than reissued.
+func (s *Sequence) NextContext(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next(ctx)
+}
+
+// ReserveContext returns the next n values from the local block, allocating
+// as many new blocks as required. If an error occurs part way through the
+// reservation, the values that were already drawn from the block are skipped.
+func (s *Sequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	if n == 0 {
+		return nil, errors.New("must reserve at least one value")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vals := make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		val, err := s.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// Returns the next value from the local block. Must be called with the lock.
+func (s *Sequence) next(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, sequence.WrapContextError(err)
+	}
+
+	if s.raft.State() != raft.Leader {
+		return 0, ErrNotLeader
+	}
+
+	if s.exhausted {
+		if err := s.allocate(ctx); err != nil {
+			return 0, err
+		}
+		s.current = s.block.First
+	} else {
+		s.current += s.block.Step
+	}
+
+	if s.current == s.block.Last {
+		s.exhausted = true
+	}
+	return s.current, nil
+}
+
+// Current returns the last value handed out by this node.
+func (s *Sequence) Current() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.block == nil {
+		return 0, errors.New("sequence has not been started")
+	}
+	return s.current, nil
+}
+
+// IsStarted returns true if this node has handed out at least one value.
+func (s *Sequence) IsStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.block != nil
+}
+
+// String returns a human readable representation of the local block.
+func (s *Sequence) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.block == nil {
+		return fmt.Sprintf("Unstarted Replicated Sequence allocating %d values per block", s.size)
+	}
+	return fmt.Sprintf("Replicated Sequence at %d, block from %d to %d incremented by %d",
+		s.current, s.block.First, s.block.Last, s.block.Step)
+}
+
+// Allocate a new block by committing an allocation to the Raft log. Any
+// values remaining in the previous block are discarded, which is safe since
+// they will never be allocated again. Must be called with the lock held.
+func (s *Sequence) allocate(ctx context.Context) error {
+	cmd, err := json.Marshal(command{Op: opAllocate, Size: s.size})
+	if err != nil {
+		return err
+	}
+
+	// Do not wait to enqueue the allocation beyond the context deadline.
+	timeout := s.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if until := time.Until(deadline); timeout == 0 || until < timeout {
+			timeout = until
+		}
+	}
+
+	future := s.raft.Apply(cmd, timeout)
+	done := make(chan error, 1)
+	go func() {
+		done <- future.Error()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return sequence.WrapContextError(ctx.Err())
+	case err := <-done:
+		if err != nil {
+			if err == raft.ErrNotLeader || err == raft.ErrLeadershipLost {
+				return ErrNotLeader
+			}
+			return err
+		}
+	}
+
+	switch rep := future.Response().(type) {
+	case *Block:
+		s.block = rep
+		s.exhausted = false
+		return nil
+	case error:
+		return rep
+	default:
+		return fmt.Errorf("unexpected response %T from replicated sequence fsm", rep)
+	}
+}
@@ -0,0 +1,236 @@
+package replicated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bbengfort/sequence"
+	"github.com/hashicorp/raft"
+)
+
+//===========================================================================
+// Cluster Helpers
+//===========================================================================
+
+// A member of an in-memory Raft cluster used for testing.
+type member struct {
+	raft  *raft.Raft
+	fsm   *FSM
+	seq   *Sequence
+	trans *raft.InmemTransport
+}
+
+// Create and bootstrap an in-memory Raft cluster with n members.
+func makeCluster(t *testing.T, n int, size uint64, params ...uint64) []*member {
+	members := make([]*member, n)
+	servers := make([]raft.Server, n)
+
+	for i := range members {
+		addr, trans := raft.NewInmemTransport(raft.NewInmemAddr())
+		servers[i] = raft.Server{ID: raft.ServerID(fmt.Sprintf("node%d", i)), Address: addr}
+		members[i] = &member{trans: trans}
+	}
+
+	// Connect every transport to every other transport.
+	for _, a := range members {
+		for _, b := range members {
+			if a != b {
+				a.trans.Connect(b.trans.LocalAddr(), b.trans)
+			}
+		}
+	}
+
+	for i, m := range members {
+		conf := raft.DefaultConfig()
+		conf.LocalID = servers[i].ID
+		conf.LogOutput = io.Discard
+		conf.HeartbeatTimeout = 50 * time.Millisecond
+		conf.ElectionTimeout = 50 * time.Millisecond
+		conf.LeaderLeaseTimeout = 50 * time.Millisecond
+		conf.CommitTimeout = 5 * time.Millisecond
+
+		var err error
+		if m.fsm, err = NewFSM(params...); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		store := raft.NewInmemStore()
+		if m.raft, err = raft.NewRaft(conf, m.fsm, store, store, raft.NewInmemSnapshotStore(), m.trans); err != nil {
+			t.Fatal(err.Error())
+		}
+		m.seq = New(m.raft, size, time.Second)
+	}
+
+	if err := members[0].raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Cleanup(func() {
+		for _, m := range members {
+			m.raft.Shutdown()
+		}
+	})
+	return members
+}
+
+// Wait for one of the running members to become the leader.
+func waitLeader(t *testing.T, members []*member) *member {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, m := range members {
+			if m.raft.State() == raft.Leader {
+				return m
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("no leader was elected")
+	return nil
+}
+
+//===========================================================================
+// Replicated Sequence Tests
+//===========================================================================
+
+// Test that the leader hands out values across multiple blocks.
+func TestReplicatedNext(t *testing.T) {
+	members := makeCluster(t, 3, 10)
+	leader := waitLeader(t, members)
+
+	for i := uint64(1); i <= 35; i++ {
+		idx, err := leader.seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx != i {
+			t.Fatalf("expected %d got %d from the leader", i, idx)
+		}
+	}
+
+	if idx, _ := leader.seq.Current(); idx != 35 {
+		t.Errorf("current value is %d not 35", idx)
+	}
+
+	// Followers must refuse to hand out values.
+	for _, m := range members {
+		if m != leader {
+			if _, err := m.seq.Next(); err != ErrNotLeader {
+				t.Errorf("follower returned %v instead of ErrNotLeader", err)
+			}
+		}
+	}
+}
+
+// Test that a failover never reissues a value handed out by the old leader.
+func TestReplicatedFailover(t *testing.T) {
+	members := makeCluster(t, 3, 10)
+	leader := waitLeader(t, members)
+
+	seen := make(map[uint64]bool)
+	var last uint64
+	for i := 0; i < 15; i++ {
+		idx, err := leader.seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		seen[idx] = true
+		last = idx
+	}
+
+	// Disconnect the leader so that the remaining members elect a new one.
+	if err := leader.raft.Shutdown().Error(); err != nil {
+		t.Fatal(err.Error())
+	}
+	leader.trans.DisconnectAll()
+
+	remaining := make([]*member, 0, len(members)-1)
+	for _, m := range members {
+		if m != leader {
+			remaining = append(remaining, m)
+		}
+	}
+
+	next := waitLeader(t, remaining)
+	for i := 0; i < 15; i++ {
+		idx, err := next.seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if seen[idx] {
+			t.Fatalf("value %d was reissued after failover", idx)
+		}
+
+		if idx <= last {
+			t.Fatalf("value %d is not greater than %d handed out before failover", idx, last)
+		}
+		seen[idx] = true
+	}
+}
+
+// Test that the leader returns an error when the sequence is exhausted.
+func TestReplicatedExhausted(t *testing.T) {
+	members := makeCluster(t, 3, 4, 10)
+	leader := waitLeader(t, members)
+
+	for i := uint64(1); i <= 10; i++ {
+		idx, err := leader.seq.Next()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if idx != i {
+			t.Fatalf("expected %d got %d from the leader", i, idx)
+		}
+	}
+
+	if _, err := leader.seq.Next(); err != ErrExhausted {
+		t.Errorf("expected ErrExhausted got %v", err)
+	}
+}
+
+// Test that a context deadline stops a partitioned leader from waiting.
+func TestReplicatedNextContext(t *testing.T) {
+	var _ sequence.ContextIncrementer = &Sequence{}
+
+	members := makeCluster(t, 3, 5)
+	leader := waitLeader(t, members)
+
+	vals, err := leader.seq.ReserveContext(context.Background(), 12)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i, val := range vals {
+		if val != uint64(i+1) {
+			t.Fatalf("expected %d got %d", i+1, val)
+		}
+	}
+
+	// Partition the leader so that new blocks can never be committed.
+	for _, m := range members {
+		m.trans.DisconnectAll()
+	}
+
+	// Values remaining in the current block are handed out without waiting.
+	for i := 0; i < 3; i++ {
+		if _, err := leader.seq.NextContext(context.Background()); err != nil && err != ErrNotLeader {
+			t.Fatal(err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := leader.seq.NextContext(ctx); err == nil {
+		t.Error("partitioned leader allocated a new block")
+	} else if err != ErrNotLeader && !errors.Is(err, sequence.ErrCanceled) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package sequence
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/bits"
+	"sync"
 )
 
 const maxuint64 = ^uint64(0) - 1
@@ -19,6 +22,11 @@ const MinimumBound = 1
 // integer: 18,446,744,073,709,551,614.
 const MaximumBound = maxuint64
 
+// ErrExhausted is wrapped by the errors returned when a sequence cannot issue
+// any more values because it has reached its minimum or maximum bound, so
+// that exhaustion can be distinguished from other errors with errors.Is.
+var ErrExhausted = errors.New("sequence exhausted")
+
 //===========================================================================
 // Sequence Structs and Interfaces
 //===========================================================================
@@ -42,6 +50,15 @@ type Incrementer interface {
 	Dump() ([]byte, error)       // Dump the sequence to a serialized representation
 }
 
+// Bounded is implemented by sequences that expose the range of values they
+// can issue, allowing callers to monitor how quickly a sequence is burning
+// through its range. Bounds does not reveal the current value of the
+// sequence, which is still only available through Current().
+type Bounded interface {
+	Bounds() (minvalue, maxvalue, increment uint64) // Returns the range and step of the sequence
+	Remaining() uint64                              // Returns the number of values Next can still return
+}
+
 // Sequence implements an AutoIncrement counter class similar to the
 // PostgreSQL sequence object. Sequence is the primary implementation of the
 // Incrementer interface. Once a Sequence has been constructed either with the
@@ -80,11 +97,13 @@ type Incrementer interface {
 // This will create a second Sequence (seq2) that is identical to the state of
 // the first Sequence (seq) when it was dumped.
 type Sequence struct {
-	current     uint64 // The current value of the sequence
-	increment   uint64 // The value to increment by (usually 1)
-	minvalue    uint64 // The minimum value of the counter (usually 1)
-	maxvalue    uint64 // The max value of the counter (usually bounded by type)
-	initialized bool   // Flag that indicates if the sequence has been initialized.
+	current     uint64       // The current value of the sequence
+	increment   uint64       // The value to increment by (usually 1)
+	minvalue    uint64       // The minimum value of the counter (usually 1)
+	maxvalue    uint64       // The max value of the counter (usually bounded by type)
+	initialized bool         // Flag that indicates if the sequence has been initialized.
+	audit       *auditor     // Records state-changing operations if set
+	alter       sync.RWMutex // Held by AtomicSequence.Alter to exclude other changes
 }
 
 // New constructs a Sequence object, and is the simplest way to create a new
@@ -224,12 +243,13 @@ func (s *Sequence) Init(params ...uint64) error {
 	}
 
 	// Ensure unsigned subtraction won't lead to a problem.
-	if int(s.minvalue)-int(s.increment) < 0 {
-		return errors.New("the minimum value must be less than or equal to the step")
+	current, ok := stepBefore(s.minvalue, s.increment)
+	if !ok {
+		return errors.New("the minimum value must be greater than or equal to the step")
 	}
 
 	// Set current based on the minvalue and the increment.
-	s.current = s.minvalue - s.increment
+	s.current = current
 
 	// Set initialized to true and return
 	s.initialized = true
@@ -238,18 +258,25 @@ func (s *Sequence) Init(params ...uint64) error {
 
 // Next updates the state of the Sequence and return the next item in the
 // sequence. It will return an error if either the minimum or the maximal
-// value has been reached.
+// value has been reached. Once the maximal value has been reached the state
+// of the sequence is no longer modified.
 func (s *Sequence) Next() (uint64, error) {
-	s.current += s.increment
-
-	// Check for missed minimum condition
-	if s.current < s.minvalue {
-		return 0, errors.New("reached minimum bound of the sequence")
+	// Ensure that the sequence has been initialized.
+	if !s.initialized {
+		return 0, errors.New("sequence has not been initialized")
 	}
 
 	// Check for reached maximum condition
-	if s.current > s.maxvalue {
-		return 0, errors.New("reached maximum bound of sequence")
+	next, ok := stepForward(s.current, 1, s.increment)
+	if !ok || next > s.maxvalue {
+		return 0, fmt.Errorf("%w: reached maximum bound of sequence", ErrExhausted)
+	}
+
+	s.current = next
+
+	// Check for missed minimum condition
+	if s.current < s.minvalue {
+		return 0, fmt.Errorf("%w: reached minimum bound of the sequence", ErrExhausted)
 	}
 
 	return s.current, nil
@@ -260,24 +287,46 @@ func (s *Sequence) Next() (uint64, error) {
 // the monotonically increasing or decreasing rule. Use with care and as a
 // fail safe if required.
 func (s *Sequence) Restart() error {
+	return s.RestartAs("")
+}
+
+// RestartAs restarts the sequence as described by Restart, attributing the
+// audit event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *Sequence) RestartAs(actor string) error {
 	// Ensure that the sequence has been initialized.
 	if !s.initialized {
 		return errors.New("sequence has not been initialized")
 	}
 
 	// Ensure unsigned subtraction won't lead to a problem.
-	if int(s.minvalue)-int(s.increment) < 0 {
-		return errors.New("the minimum value must be less than or equal to the step")
+	current, ok := stepBefore(s.minvalue, s.increment)
+	if !ok {
+		return errors.New("the minimum value must be greater than or equal to the step")
 	}
 
 	// Set current based on the minvalue and the increment.
-	s.current = s.minvalue - s.increment
-	return nil
+	old := s.current
+	s.current = current
+	return s.record(AuditRestart, actor, old, s.current)
 }
 
 // Update the sequence to the current value. If the update value violates the
-// monotonically increasing or decreasing rule, an error is returned.
+// monotonically increasing or decreasing rule or is greater than the maximum
+// value, an error is returned.
 func (s *Sequence) Update(val uint64) error {
+	return s.UpdateAs(val, "")
+}
+
+// UpdateAs updates the sequence as described by Update, attributing the audit
+// event to the actor instead of the actor given to SetAudit unless it is
+// empty.
+func (s *Sequence) UpdateAs(val uint64, actor string) error {
+	// maximum bound error
+	if val > s.maxvalue {
+		return errors.New("cannot update sequence beyond its maximum value")
+	}
+
 	// monotonically increasing error
 	if s.increment > 0 && val < s.current {
 		return errors.New("cannot decrease monotonically increasing sequence")
@@ -289,8 +338,84 @@ func (s *Sequence) Update(val uint64) error {
 	}
 
 	// Update the sequence.
+	old := s.current
 	s.current = val
-	return nil
+	return s.record(AuditUpdate, actor, old, val)
+}
+
+//===========================================================================
+// Sequence Context Methods
+//===========================================================================
+
+// NextContext returns the next value of the sequence unless the context is
+// done. A Sequence never waits for values, so the context is only checked
+// before the state of the sequence is updated.
+func (s *Sequence) NextContext(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, WrapContextError(err)
+	}
+	return s.Next()
+}
+
+// ReserveContext reserves the next n values of the sequence unless the
+// context is done. Either all n values are reserved or the state of the
+// sequence is unchanged and an error is returned, e.g. if fewer than n values
+// remain before the maximum bound.
+func (s *Sequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, WrapContextError(err)
+	}
+
+	first, last, err := s.reserve(n)
+	if err != nil {
+		return nil, err
+	}
+	return reserved(first, last, s.increment), nil
+}
+
+// Reserve the next n values of the sequence, returning the first and last
+// values of the reserved block.
+func (s *Sequence) reserve(n uint64) (first, last uint64, err error) {
+	if !s.initialized {
+		return 0, 0, errors.New("sequence has not been initialized")
+	}
+
+	if n == 0 {
+		return 0, 0, errors.New("must reserve at least one value")
+	}
+
+	last, ok := stepForward(s.current, n, s.increment)
+	if !ok || last > s.maxvalue {
+		rem := remaining(s.current, s.increment, s.maxvalue)
+		return 0, 0, fmt.Errorf("%w: cannot reserve %d values, only %d remaining in sequence", ErrExhausted, n, rem)
+	}
+
+	first, _ = stepForward(s.current, 1, s.increment)
+	s.current = last
+	return first, last, nil
+}
+
+// Returns the number of values that can be returned by Next before the
+// maximum value is reached. The increment must not be zero.
+func remaining(current, increment, maxvalue uint64) uint64 {
+	if current >= maxvalue {
+		return 0
+	}
+	return (maxvalue - current) / increment
+}
+
+// Returns current + n*increment, or false if the result would overflow.
+func stepForward(current, n, increment uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(n, increment)
+	sum, carry := bits.Add64(current, lo, 0)
+	return sum, hi == 0 && carry == 0
+}
+
+// Returns the current value of a sequence that has not issued any values,
+// one step before the minimum value, or false if it would underflow.
+func stepBefore(minvalue, increment uint64) (uint64, bool) {
+	diff, borrow := bits.Sub64(minvalue, increment, 0)
+	return diff, borrow == 0
 }
 
 //===========================================================================
@@ -314,24 +439,42 @@ func (s *Sequence) Current() (uint64, error) {
 	return s.current, nil
 }
 
-// IsStarted returns the state of the Sequence (started or stopped). This
-// method returns true if the current value is greater than or equal to the
-// minimum value and if it is less than the maximal value. This method will
-// also return false if the Sequence is not yet initialized.
+// IsStarted returns true if the Sequence has issued a value, including if it
+// has been exhausted. This method will return false if the Sequence is not
+// yet initialized or has been restarted. Use State to tell an active sequence
+// from an exhausted one.
 func (s *Sequence) IsStarted() bool {
+	state := s.State()
+	return state == Active || state == Exhausted
+}
+
+// Bounds returns the minimum value, maximum value and increment the sequence
+// was initialized with. All values are zero if it is not initialized.
+func (s *Sequence) Bounds() (minvalue, maxvalue, increment uint64) {
+	return s.minvalue, s.maxvalue, s.increment
+}
+
+// Remaining returns the number of values that can still be returned by Next
+// before the sequence reaches its maximum bound. Remaining returns zero if
+// the sequence is not initialized.
+func (s *Sequence) Remaining() uint64 {
 	if !s.initialized {
-		return false
+		return 0
 	}
-	return !(s.current < s.minvalue) && s.current < s.maxvalue
+	return remaining(s.current, s.increment, s.maxvalue)
 }
 
 // String returns a human readable representation of the sequence.
 func (s *Sequence) String() string {
 	d := fmt.Sprintf("incremented by %d between %d and %d", s.increment, s.minvalue, s.maxvalue)
-	if !s.IsStarted() {
+	switch s.State() {
+	case Active:
+		return fmt.Sprintf("Sequence at %d, %s", s.current, d)
+	case Exhausted:
+		return fmt.Sprintf("Exhausted Sequence at %d, %s", s.current, d)
+	default:
 		return fmt.Sprintf("Unstarted Sequence %s", d)
 	}
-	return fmt.Sprintf("Sequence at %d, %s", s.current, d)
 }
 
 //===========================================================================
@@ -342,15 +485,16 @@ func (s *Sequence) String() string {
 // The data that is dumped from this method can be loaded by an uninitialized
 // Sequence to bring it as up to date as the sequence state when it was
 // dumped. This method is intended to allow cross process communication of the
-// sequence state.
+// sequence state. Sequences in every state other than Uninitialized can be
+// dumped, and the loaded sequence is in the same state.
 //
 // Note, however, that the autoincrement invariant is not satisfied during
 // concurrent access. Therefore Dump and Load should be used with locks to
 // ensure that the system does not end up diverging the state of the Sequence.
 // It is up to the calling library to implement these locks.
 func (s *Sequence) Dump() ([]byte, error) {
-	if !s.IsStarted() {
-		return nil, errors.New("cannot dump an uninitialized or unstarted sequence")
+	if !s.initialized {
+		return nil, errors.New("cannot dump an uninitialized sequence")
 	}
 
 	data := make(map[string]uint64)
@@ -367,34 +511,41 @@ func (s *Sequence) Dump() ([]byte, error) {
 // Dump method. If the data does not match the Sequence specification this
 // method will return an error. Note that different versions of the sequence
 // library could lead to errors.
+//
+// The loaded state is validated against the same invariants that Init
+// enforces; if a field is missing or violates an invariant, an
+// *InvariantError is returned and the sequence is not modified. Unknown
+// fields are ignored, use LoadStrict to reject them.
 func (s *Sequence) Load(data []byte) error {
-	if s.initialized {
-		return errors.New("cannot load into an initialized sequence")
-	}
-
-	vals := make(map[string]uint64)
-	if err := json.Unmarshal(data, &vals); err != nil {
-		return err
-	}
-
-	var ok bool
+	return s.load(data, false, "")
+}
 
-	if s.current, ok = vals["current"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	}
+// LoadAs loads the sequence as described by Load, attributing the audit event
+// to the actor instead of the actor given to SetAudit unless it is empty.
+func (s *Sequence) LoadAs(data []byte, actor string) error {
+	return s.load(data, false, actor)
+}
 
-	if s.increment, ok = vals["increment"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
-	}
+// LoadStrict loads the sequence as described by Load, but also returns an
+// *InvariantError wrapping ErrUnknownField if the data has fields that are
+// not part of the format exported by Dump.
+func (s *Sequence) LoadStrict(data []byte) error {
+	return s.load(data, true, "")
+}
 
-	if s.minvalue, ok = vals["minvalue"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
+// Load the sequence by the actor, rejecting unknown fields if strict.
+func (s *Sequence) load(data []byte, strict bool, actor string) error {
+	if s.initialized {
+		return errors.New("cannot load into an initialized sequence")
 	}
 
-	if s.maxvalue, ok = vals["maxvalue"]; !ok {
-		return errors.New("improperly formatted data or sequence version")
+	loaded, err := parse(data, strict)
+	if err != nil {
+		return err
 	}
 
+	s.current, s.increment = loaded.current, loaded.increment
+	s.minvalue, s.maxvalue = loaded.minvalue, loaded.maxvalue
 	s.initialized = true
-	return nil
+	return s.record(AuditLoad, actor, 0, s.current)
 }
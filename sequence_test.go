@@ -1,7 +1,9 @@
 package sequence
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -282,6 +284,37 @@ func TestIsStarted(t *testing.T) {
 	}
 }
 
+// Test the bounds and remaining functionality
+func TestBoundsRemaining(t *testing.T) {
+	var _ Bounded = &Sequence{}
+
+	seq := new(Sequence)
+	if seq.Remaining() != 0 {
+		t.Error("uninitialized sequence has remaining values")
+	}
+
+	seq.Init(2, 20, 2)
+	if min, max, inc := seq.Bounds(); min != 2 || max != 20 || inc != 2 {
+		t.Errorf("unexpected bounds %d, %d, %d", min, max, inc)
+	}
+
+	for i := uint64(10); i > 0; i-- {
+		if rem := seq.Remaining(); rem != i {
+			t.Fatalf("expected %d remaining got %d", i, rem)
+		}
+		seq.Next()
+	}
+
+	if rem := seq.Remaining(); rem != 0 {
+		t.Errorf("expected 0 remaining got %d", rem)
+	}
+
+	seq.Next()
+	if rem := seq.Remaining(); rem != 0 {
+		t.Errorf("expected 0 remaining after exhaustion got %d", rem)
+	}
+}
+
 // An example of the human readable state of a sequence.
 func ExampleSequence_String() {
 	seq, _ := New()
@@ -561,7 +594,7 @@ func Test4ArgInit(t *testing.T) {
 // Test that sequence goes to the maximum value then errors
 func TestCeiling(t *testing.T) {
 	// Create a sequence right at the maximum bound.
-	seq := &Sequence{MaximumBound - 1, 1, MinimumBound, MaximumBound, true}
+	seq := &Sequence{current: MaximumBound - 1, increment: 1, minvalue: MinimumBound, maxvalue: MaximumBound, initialized: true}
 
 	idx, err := seq.Next()
 	if err != nil {
@@ -598,7 +631,7 @@ func TestIncrement(t *testing.T) {
 // Test that sequence goes to the maximum value then errors on increment
 func TestCeilingIncrement(t *testing.T) {
 	// Create a sequence right at the maximum bound.
-	seq := &Sequence{MaximumBound - 1, 2, MinimumBound, MaximumBound, true}
+	seq := &Sequence{current: MaximumBound - 1, increment: 2, minvalue: MinimumBound, maxvalue: MaximumBound, initialized: true}
 
 	jdx, err := seq.Next()
 	if err == nil {
@@ -669,6 +702,88 @@ func TestRange(t *testing.T) {
 	}
 }
 
+// Test that arithmetic at the extremes of the uint64 range never wraps around.
+func TestOverflow(t *testing.T) {
+	tests := []struct {
+		min, max, step uint64
+		expected       []uint64
+	}{
+		{1 << 63, MaximumBound, 1 << 62, []uint64{1 << 63, 1<<63 + 1<<62}},
+		{math.MaxInt64 + 2, MaximumBound, math.MaxInt64, []uint64{math.MaxInt64 + 2}},
+		{MaximumBound, MaximumBound, MaximumBound, []uint64{MaximumBound}},
+		{MaximumBound - 1, MaximumBound, 2, []uint64{MaximumBound - 1}},
+	}
+
+	for _, tt := range tests {
+		for _, seq := range []Incrementer{new(Sequence), new(AtomicSequence)} {
+			if err := seq.Init(tt.min, tt.max, tt.step); err != nil {
+				t.Fatalf("could not init sequence between %d and %d by %d: %s", tt.min, tt.max, tt.step, err)
+			}
+
+			for _, e := range tt.expected {
+				if val, err := seq.Next(); err != nil || val != e {
+					t.Fatalf("expected %d got %d (%v)", e, val, err)
+				}
+			}
+
+			if val, err := seq.Next(); !errors.Is(err, ErrExhausted) {
+				t.Errorf("expected ErrExhausted after %d, got %d (%v)", tt.expected[len(tt.expected)-1], val, err)
+			}
+
+			if val, _ := seq.Current(); val != tt.expected[len(tt.expected)-1] {
+				t.Errorf("failed Next modified the state to %d", val)
+			}
+		}
+	}
+}
+
+// Test that a minimum value less than the step is rejected above MaxInt64.
+func TestOverflowInit(t *testing.T) {
+	tests := [][]uint64{
+		{1 << 63, MaximumBound, 1<<63 + 1},
+		{math.MaxInt64, MaximumBound, MaximumBound},
+		{2, 10, 3},
+	}
+
+	for _, args := range tests {
+		if _, err := New(args...); err == nil {
+			t.Errorf("expected error initializing with %v", args)
+		}
+
+		if _, err := NewAtomic(args...); err == nil {
+			t.Errorf("expected error initializing atomic with %v", args)
+		}
+	}
+}
+
+// Test that reserving more values than fit in a uint64 does not wrap around.
+func TestOverflowReserve(t *testing.T) {
+	tests := []struct {
+		n, step uint64
+	}{
+		{math.MaxUint64, 1},
+		{1 << 32, 1 << 32},
+		{3, math.MaxInt64},
+		{2, 1 << 63},
+	}
+
+	for _, tt := range tests {
+		seq, _ := New(tt.step, MaximumBound, tt.step)
+		if _, _, err := seq.reserve(tt.n); !errors.Is(err, ErrExhausted) {
+			t.Errorf("expected ErrExhausted reserving %d values by %d, got %v", tt.n, tt.step, err)
+		}
+
+		aseq, _ := NewAtomic(tt.step, MaximumBound, tt.step)
+		if _, _, err := aseq.reserve(tt.n); !errors.Is(err, ErrExhausted) {
+			t.Errorf("expected ErrExhausted reserving %d atomic values by %d, got %v", tt.n, tt.step, err)
+		}
+
+		if seq.State() != Ready || aseq.State() != Ready {
+			t.Errorf("failed reserve modified the state to %s and %s", seq, aseq)
+		}
+	}
+}
+
 //===========================================================================
 // Benchmarks
 //===========================================================================
@@ -0,0 +1,241 @@
+package sequence
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ShuffledSequence visits every value of a bounded range exactly once in a
+// pseudo-random order, e.g. to sample a range or to generate load without
+// hot spots. The order is determined by a seed and is a Permutation of the
+// positions of the values in the range, so a ShuffledSequence only keeps the
+// number of values that have been issued and the seed as its state, however
+// large the range is.
+//
+// A ShuffledSequence is initialized with the same parameters as a Sequence
+// and returns an error from Next once every value has been issued. Restart
+// repeats the same order; the order can only be changed with a new seed.
+type ShuffledSequence struct {
+	position  Sequence     // Counts the values issued from 1 to the size of the range
+	seed      uint64       // Determines the order the values are issued in
+	minvalue  uint64       // The minimum value of the range
+	maxvalue  uint64       // The maximum value of the range
+	increment uint64       // The step between values in the range
+	perm      *Permutation // Maps positions to offsets in the range
+}
+
+// NewShuffled constructs a ShuffledSequence whose order is determined by the
+// seed, over the range specified by the params as described by Sequence.Init.
+// If the seed is zero a random seed is used.
+func NewShuffled(seed uint64, params ...uint64) (*ShuffledSequence, error) {
+	seq := &ShuffledSequence{seed: seed}
+	err := seq.Init(params...)
+	return seq, err
+}
+
+// Init the range of the sequence as described by Sequence.Init. If the
+// sequence was not created by NewShuffled, a random seed is used.
+func (s *ShuffledSequence) Init(params ...uint64) error {
+	if s.position.initialized {
+		return errors.New("cannot re-initialize a sequence object")
+	}
+
+	// Validate the parameters the same way as a Sequence.
+	bounds := new(Sequence)
+	if err := bounds.Init(params...); err != nil {
+		return err
+	}
+
+	for s.seed == 0 {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return fmt.Errorf("could not generate seed: %w", err)
+		}
+		s.seed = binary.BigEndian.Uint64(buf[:])
+	}
+
+	return s.init(bounds.minvalue, bounds.maxvalue, bounds.increment)
+}
+
+// Initialize the position and permutation of the range.
+func (s *ShuffledSequence) init(minvalue, maxvalue, increment uint64) error {
+	size := (maxvalue-minvalue)/increment + 1
+
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], s.seed)
+
+	perm, err := NewPermutation(key[:], 0, size-1)
+	if err != nil {
+		return err
+	}
+
+	s.position = Sequence{}
+	if err := s.position.Init(size); err != nil {
+		return err
+	}
+
+	s.minvalue, s.maxvalue, s.increment = minvalue, maxvalue, increment
+	s.perm = perm
+	return nil
+}
+
+// Returns the value issued at the position, which must be in the range.
+func (s *ShuffledSequence) value(position uint64) uint64 {
+	offset, _ := s.perm.Permute(position - 1)
+	return s.minvalue + offset*s.increment
+}
+
+// Seed returns the seed that determines the order of the sequence.
+func (s *ShuffledSequence) Seed() uint64 {
+	return s.seed
+}
+
+// Next returns the next value in the shuffled order, or an error once every
+// value in the range has been issued.
+func (s *ShuffledSequence) Next() (uint64, error) {
+	position, err := s.position.Next()
+	if err != nil {
+		return 0, err
+	}
+	return s.value(position), nil
+}
+
+// Restart the sequence so that the values are issued again in the same order.
+func (s *ShuffledSequence) Restart() error {
+	return s.position.Restart()
+}
+
+// Update the sequence so that val is the current value, skipping the values
+// that would have been issued before it. An error is returned if val is not
+// in the range or if it has already been issued.
+func (s *ShuffledSequence) Update(val uint64) error {
+	if !s.position.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	if val < s.minvalue || val > s.maxvalue || (val-s.minvalue)%s.increment != 0 {
+		return fmt.Errorf("value %d is not in the range of the sequence", val)
+	}
+
+	// Find the position at which the value is issued.
+	offset, err := s.perm.Invert((val - s.minvalue) / s.increment)
+	if err != nil {
+		return err
+	}
+
+	if offset+1 < s.position.current {
+		return errors.New("cannot update shuffled sequence to a value that has already been issued")
+	}
+	return s.position.Update(offset + 1)
+}
+
+// Current returns the value most recently returned by Next.
+func (s *ShuffledSequence) Current() (uint64, error) {
+	position, err := s.position.Current()
+	if err != nil {
+		return 0, err
+	}
+	return s.value(position), nil
+}
+
+// IsStarted returns true if Next has issued a value, including if every value
+// has been issued, as described by Sequence.IsStarted.
+func (s *ShuffledSequence) IsStarted() bool {
+	return s.position.IsStarted()
+}
+
+// State returns the current state of the sequence as described by State.
+func (s *ShuffledSequence) State() State {
+	return s.position.State()
+}
+
+// Bounds returns the minimum value, maximum value and increment of the range.
+func (s *ShuffledSequence) Bounds() (minvalue, maxvalue, increment uint64) {
+	return s.minvalue, s.maxvalue, s.increment
+}
+
+// Remaining returns the number of values that have not yet been issued.
+func (s *ShuffledSequence) Remaining() uint64 {
+	return s.position.Remaining()
+}
+
+// String returns a human readable representation of the sequence.
+func (s *ShuffledSequence) String() string {
+	d := fmt.Sprintf("of %d values incremented by %d between %d and %d", s.position.maxvalue, s.increment, s.minvalue, s.maxvalue)
+	switch s.State() {
+	case Active:
+		return fmt.Sprintf("Shuffled Sequence at position %d %s", s.position.current, d)
+	case Exhausted:
+		return fmt.Sprintf("Exhausted Shuffled Sequence at position %d %s", s.position.current, d)
+	default:
+		return fmt.Sprintf("Unstarted Shuffled Sequence %s", d)
+	}
+}
+
+// Dump the position, seed and range of the sequence into a JSON binary
+// representation, as described by Sequence.Dump.
+func (s *ShuffledSequence) Dump() ([]byte, error) {
+	if !s.position.initialized {
+		return nil, errors.New("cannot dump an uninitialized sequence")
+	}
+
+	data := make(map[string]uint64)
+	data["position"] = s.position.current
+	data["seed"] = s.seed
+	data["increment"] = s.increment
+	data["minvalue"] = s.minvalue
+	data["maxvalue"] = s.maxvalue
+
+	return json.Marshal(data)
+}
+
+// Load an uninitialized sequence from data exported by Dump. The loaded
+// sequence continues in the same order from the same position. The data is
+// validated as described by Sequence.Load.
+func (s *ShuffledSequence) Load(data []byte) error {
+	return s.load(data, false)
+}
+
+// LoadStrict loads the sequence as described by Load, rejecting unknown
+// fields as described by Sequence.LoadStrict.
+func (s *ShuffledSequence) LoadStrict(data []byte) error {
+	return s.load(data, true)
+}
+
+// Load the sequence, rejecting unknown fields if strict.
+func (s *ShuffledSequence) load(data []byte, strict bool) error {
+	if s.position.initialized {
+		return errors.New("cannot load into an initialized sequence")
+	}
+
+	vals, err := decodeFields[uint64](data, strict, []string{"position", "seed", "increment", "minvalue", "maxvalue"})
+	if err != nil {
+		return err
+	}
+
+	if vals["seed"] == 0 {
+		return &InvariantError{Field: "seed", Err: ErrOutOfBounds}
+	}
+
+	// Validate the range the same way as a Sequence.
+	bounds := &Sequence{current: vals["minvalue"], increment: vals["increment"], minvalue: vals["minvalue"], maxvalue: vals["maxvalue"]}
+	if err := bounds.validate(); err != nil {
+		return err
+	}
+
+	// The position counts from 1 to the size of the range.
+	if vals["position"] > (vals["maxvalue"]-vals["minvalue"])/vals["increment"]+1 {
+		return &InvariantError{Field: "position", Err: ErrOutOfRange}
+	}
+
+	s.seed = vals["seed"]
+	if err := s.init(vals["minvalue"], vals["maxvalue"], vals["increment"]); err != nil {
+		return err
+	}
+
+	s.position.current = vals["position"]
+	return nil
+}
@@ -0,0 +1,236 @@
+package sequence
+
+import (
+	"errors"
+	"testing"
+)
+
+// Ensure that the shuffled sequence implements the sequence interfaces.
+func TestShuffledInterface(t *testing.T) {
+	var _ Incrementer = &ShuffledSequence{}
+	var _ Bounded = &ShuffledSequence{}
+}
+
+// Test that every value in the range is issued exactly once.
+func TestShuffledFullPeriod(t *testing.T) {
+	tests := [][]uint64{{1}, {7}, {100}, {10, 20}, {3, 300, 3}, {5, 1000, 5}}
+
+	for _, params := range tests {
+		seq, err := NewShuffled(42, params...)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		minvalue, maxvalue, increment := seq.Bounds()
+		size := (maxvalue-minvalue)/increment + 1
+
+		seen := make(map[uint64]bool)
+		inOrder := true
+		for i := uint64(0); i < size; i++ {
+			if rem := seq.Remaining(); rem != size-i {
+				t.Fatalf("expected %d remaining got %d", size-i, rem)
+			}
+
+			val, err := seq.Next()
+			if err != nil {
+				t.Fatalf("%v: %s", params, err)
+			}
+
+			if val < minvalue || val > maxvalue || (val-minvalue)%increment != 0 {
+				t.Fatalf("%v: issued %d outside of the range", params, val)
+			}
+
+			if seen[val] {
+				t.Fatalf("%v: issued %d twice", params, val)
+			}
+			seen[val] = true
+
+			if val != minvalue+i*increment {
+				inOrder = false
+			}
+		}
+
+		if size >= 10 && inOrder {
+			t.Errorf("%v: values were not shuffled", params)
+		}
+
+		if _, err := seq.Next(); !errors.Is(err, ErrExhausted) {
+			t.Errorf("%v: expected exhausted error got %v", params, err)
+		}
+	}
+}
+
+// Test that the order is determined by the seed and repeated on restart.
+func TestShuffledSeed(t *testing.T) {
+	a, _ := NewShuffled(1, 1000)
+	b, _ := NewShuffled(1, 1000)
+	c, _ := NewShuffled(2, 1000)
+	d, _ := NewShuffled(0, 1000)
+
+	if d.Seed() == 0 {
+		t.Error("no random seed was generated")
+	}
+
+	var first []uint64
+	different := 0
+	for i := 0; i < 100; i++ {
+		va, _ := a.Next()
+		vb, _ := b.Next()
+		vc, _ := c.Next()
+
+		if va != vb {
+			t.Fatal("the same seed produced different orders")
+		}
+
+		if va != vc {
+			different++
+		}
+		first = append(first, va)
+	}
+
+	if different < 90 {
+		t.Error("different seeds produced similar orders")
+	}
+
+	if err := a.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, expected := range first {
+		if val, _ := a.Next(); val != expected {
+			t.Fatalf("restart changed the order: expected %d got %d", expected, val)
+		}
+	}
+}
+
+// Test the current value and updating the position.
+func TestShuffledCurrentUpdate(t *testing.T) {
+	seq, _ := NewShuffled(7, 100)
+	if _, err := seq.Current(); err == nil {
+		t.Error("unstarted sequence returned a current value")
+	}
+
+	vals := make([]uint64, 10)
+	for i := range vals {
+		vals[i], _ = seq.Next()
+		if current, err := seq.Current(); err != nil || current != vals[i] {
+			t.Errorf("expected current %d got %d (%v)", vals[i], current, err)
+		}
+	}
+
+	// Updating to an issued value is an error.
+	if err := seq.Update(vals[3]); err == nil {
+		t.Error("updated the sequence to an issued value")
+	}
+
+	// Updating to the current value does nothing.
+	if err := seq.Update(vals[9]); err != nil {
+		t.Errorf("could not update to the current value: %s", err)
+	}
+
+	// Skip ahead by finding values that come later in the order.
+	clone, _ := NewShuffled(7, 100)
+	var skipped []uint64
+	for i := 0; i < 15; i++ {
+		val, _ := clone.Next()
+		skipped = append(skipped, val)
+	}
+
+	if err := seq.Update(skipped[12]); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != skipped[13] {
+		t.Errorf("expected %d after update got %d", skipped[13], val)
+	}
+
+	for _, val := range []uint64{0, 101} {
+		if err := seq.Update(val); err == nil {
+			t.Errorf("updated to %d outside of the range", val)
+		}
+	}
+}
+
+// Test that the sequence resumes in the same order after a dump and load.
+func TestShuffledDumpLoad(t *testing.T) {
+	seq, _ := NewShuffled(0, 10, 1000, 10)
+	if _, err := new(ShuffledSequence).Dump(); err == nil {
+		t.Error("dumped an uninitialized sequence")
+	}
+
+	for i := 0; i < 50; i++ {
+		seq.Next()
+	}
+
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded := new(ShuffledSequence)
+	if err := loaded.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if loaded.Seed() != seq.Seed() || loaded.String() != seq.String() {
+		t.Errorf("loaded %s does not match %s", loaded, seq)
+	}
+
+	bad := []string{
+		`{"position":1,"seed":0,"increment":10,"minvalue":10,"maxvalue":1000}`,
+		`{"position":1,"seed":1,"increment":0,"minvalue":10,"maxvalue":1000}`,
+		`{"position":1,"seed":1,"increment":10,"minvalue":1000,"maxvalue":10}`,
+		`{"position":101,"seed":1,"increment":10,"minvalue":10,"maxvalue":1000}`,
+	}
+
+	for _, data := range bad {
+		if err := new(ShuffledSequence).Load([]byte(data)); !errors.As(err, new(*InvariantError)) {
+			t.Errorf("expected invariant error loading %s, got %v", data, err)
+		}
+	}
+
+	extra := []byte(`{"position":1,"seed":1,"increment":10,"minvalue":10,"maxvalue":1000,"extra":1}`)
+	if err := new(ShuffledSequence).LoadStrict(extra); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		expected, _ := seq.Next()
+		if val, err := loaded.Next(); err != nil || val != expected {
+			t.Fatalf("expected %d got %d (%v)", expected, val, err)
+		}
+	}
+
+	if err := loaded.Load(data); err == nil {
+		t.Error("loaded into an initialized sequence")
+	}
+
+	for _, bad := range []string{
+		`{}`,
+		`{"position": 1, "seed": 0, "increment": 1, "minvalue": 1, "maxvalue": 10}`,
+		`{"position": 11, "seed": 1, "increment": 1, "minvalue": 1, "maxvalue": 10}`,
+		`{"position": 1, "seed": 1, "increment": 1, "minvalue": 10, "maxvalue": 1}`,
+		`{"position": 1, "seed": 1, "increment": 0, "minvalue": 1, "maxvalue": 10}`,
+		`not json`,
+	} {
+		if err := new(ShuffledSequence).Load([]byte(bad)); err == nil {
+			t.Errorf("loaded bad data %s", bad)
+		}
+	}
+}
+
+// Test that bad parameters are rejected like a Sequence.
+func TestShuffledInitErrors(t *testing.T) {
+	if _, err := NewShuffled(1, 0); err == nil {
+		t.Error("initialized a sequence with a zero maximum")
+	}
+
+	if _, err := NewShuffled(1, 10, 1); err == nil {
+		t.Error("initialized a sequence with an empty range")
+	}
+
+	seq, _ := NewShuffled(1)
+	if err := seq.Init(); err == nil {
+		t.Error("re-initialized a sequence")
+	}
+}
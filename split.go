@@ -0,0 +1,140 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+)
+
+//===========================================================================
+// Sequence Partitioning
+//===========================================================================
+
+// Split carves the values that the sequence has not yet issued into n
+// disjoint child sequences with the same step, e.g. to hand a range to
+// parallel workers. The children cover consecutive sub-ranges of as equal a
+// size as possible. The state of the sequence is not modified; it should not
+// be used until the workers are done and their progress has been recorded
+// with Merge. An error is returned if fewer than n values remain.
+func (s *Sequence) Split(n int) ([]*Sequence, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+
+	if n < 1 {
+		return nil, errors.New("must split a sequence into at least one child")
+	}
+
+	rem := s.Remaining()
+	if rem < uint64(n) {
+		return nil, fmt.Errorf("%w: cannot split %d values into %d sequences", ErrExhausted, rem, n)
+	}
+
+	size, extra := rem/uint64(n), rem%uint64(n)
+	starts := make([]uint64, 0, n-1)
+	next := s.current + s.increment
+	for i := uint64(0); i < uint64(n-1); i++ {
+		count := size
+		if i < extra {
+			count++
+		}
+		next += count * s.increment
+		starts = append(starts, next)
+	}
+	return s.split(starts), nil
+}
+
+// SplitAt carves the values that the sequence has not yet issued into child
+// sequences with the same step that start at each of the given values; the
+// first child starts at the next value of the sequence. The values must be
+// strictly increasing, must not have been issued and must be on the step of
+// the sequence. As with Split, the state of the sequence is not modified.
+func (s *Sequence) SplitAt(values ...uint64) ([]*Sequence, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+
+	if s.Remaining() == 0 {
+		return nil, fmt.Errorf("%w: cannot split an exhausted sequence", ErrExhausted)
+	}
+
+	prev := s.current + s.increment
+	for _, val := range values {
+		if val <= prev || val > s.maxvalue {
+			return nil, fmt.Errorf("cannot split at %d: values must be increasing and between %d and %d", val, prev+1, s.maxvalue)
+		}
+
+		if (val-s.minvalue)%s.increment != 0 {
+			return nil, fmt.Errorf("cannot split at %d: not a value of the sequence", val)
+		}
+		prev = val
+	}
+	return s.split(values), nil
+}
+
+// Create children that start at the next value and at each of the starts.
+func (s *Sequence) split(starts []uint64) []*Sequence {
+	children := make([]*Sequence, 0, len(starts)+1)
+	first := s.current + s.increment
+	for i := 0; i <= len(starts); i++ {
+		last := s.current + s.Remaining()*s.increment
+		if i < len(starts) {
+			last = starts[i] - s.increment
+		}
+
+		children = append(children, &Sequence{
+			current:     first - s.increment,
+			increment:   s.increment,
+			minvalue:    first,
+			maxvalue:    last,
+			initialized: true,
+		})
+
+		if i < len(starts) {
+			first = starts[i]
+		}
+	}
+	return children
+}
+
+// Merge records the progress of child sequences created by Split or SplitAt,
+// updating the sequence to the largest value issued by any of the children so
+// that it continues after that high-water mark. Values that the children did
+// not issue below the high-water mark are never issued. An error is returned
+// if a child is not part of the range of the sequence.
+func (s *Sequence) Merge(children ...*Sequence) error {
+	if !s.initialized {
+		return errors.New("sequence has not been initialized")
+	}
+
+	highwater := s.current
+	for i, child := range children {
+		if !child.initialized || child.increment != s.increment || child.minvalue < s.minvalue || child.maxvalue > s.maxvalue || (child.minvalue-s.minvalue)%s.increment != 0 {
+			return fmt.Errorf("child %d is not a partition of the sequence", i)
+		}
+
+		// Skip children that have not issued any values.
+		if child.current < child.minvalue {
+			continue
+		}
+
+		if child.current > highwater {
+			highwater = child.current
+		}
+	}
+	return s.Update(highwater)
+}
+
+// TotalRemaining returns the combined number of values that the sequences can
+// still issue, e.g. the remaining capacity of the children of a Split. The
+// total saturates at the largest uint64 value rather than overflowing.
+func TotalRemaining(seqs ...Bounded) uint64 {
+	var total uint64
+	for _, seq := range seqs {
+		rem := seq.Remaining()
+		if total+rem < total {
+			return ^uint64(0)
+		}
+		total += rem
+	}
+	return total
+}
@@ -0,0 +1,168 @@
+package sequence
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test splitting an unstarted sequence into disjoint children.
+func TestSplit(t *testing.T) {
+	seq, _ := New(2, 200, 2)
+	children, err := seq.Split(3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := [][2]uint64{{2, 68}, {70, 134}, {136, 200}}
+	if len(children) != len(expected) {
+		t.Fatalf("expected %d children got %d", len(expected), len(children))
+	}
+
+	seen := make(map[uint64]bool)
+	for i, child := range children {
+		minvalue, maxvalue, increment := child.Bounds()
+		if minvalue != expected[i][0] || maxvalue != expected[i][1] || increment != 2 {
+			t.Errorf("child %d has bounds %d to %d by %d", i, minvalue, maxvalue, increment)
+		}
+
+		for {
+			val, err := child.Next()
+			if err != nil {
+				break
+			}
+
+			if seen[val] {
+				t.Fatalf("value %d issued by two children", val)
+			}
+			seen[val] = true
+		}
+	}
+
+	if len(seen) != 100 {
+		t.Errorf("expected the children to issue 100 values got %d", len(seen))
+	}
+
+	// The parent is not modified by splitting.
+	if seq.IsStarted() || seq.Remaining() != 100 {
+		t.Errorf("split modified the parent: %s", seq)
+	}
+}
+
+// Test splitting a partially consumed sequence.
+func TestSplitPartial(t *testing.T) {
+	seq, _ := New(100)
+	for i := 0; i < 10; i++ {
+		seq.Next()
+	}
+
+	children, err := seq.Split(4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	counts := []uint64{23, 23, 22, 22}
+	first := uint64(11)
+	for i, child := range children {
+		if child.Remaining() != counts[i] {
+			t.Errorf("expected child %d to have %d values got %d", i, counts[i], child.Remaining())
+		}
+
+		if val, _ := child.Next(); val != first {
+			t.Errorf("expected child %d to start at %d got %d", i, first, val)
+		}
+		first += counts[i]
+	}
+
+	if total := TotalRemaining(children[0], children[1], children[2], children[3]); total != 86 {
+		t.Errorf("expected 86 values remaining got %d", total)
+	}
+
+	if _, err := seq.Split(91); !errors.Is(err, ErrExhausted) {
+		t.Errorf("expected exhausted error got %v", err)
+	}
+
+	if _, err := seq.Split(0); err == nil {
+		t.Error("split into zero children")
+	}
+}
+
+// Test splitting at specific values.
+func TestSplitAt(t *testing.T) {
+	seq, _ := New(5, 1000, 5)
+	children, err := seq.SplitAt(500, 750)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := [][2]uint64{{5, 495}, {500, 745}, {750, 1000}}
+	for i, child := range children {
+		minvalue, maxvalue, _ := child.Bounds()
+		if minvalue != expected[i][0] || maxvalue != expected[i][1] {
+			t.Errorf("child %d has bounds %d to %d", i, minvalue, maxvalue)
+		}
+	}
+
+	if children, err := seq.SplitAt(); err != nil || len(children) != 1 || children[0].Remaining() != 200 {
+		t.Errorf("expected a single child with every value: %v", err)
+	}
+
+	for _, values := range [][]uint64{{5}, {500, 500}, {750, 500}, {1005}, {502}} {
+		if _, err := seq.SplitAt(values...); err == nil {
+			t.Errorf("split at bad values %v", values)
+		}
+	}
+}
+
+// Test merging the progress of children into the parent.
+func TestMerge(t *testing.T) {
+	seq, _ := New(1000)
+	children, _ := seq.Split(4)
+
+	// The first child is exhausted, the second is partially consumed and the
+	// others have not issued values.
+	for {
+		if _, err := children[0].Next(); err != nil {
+			break
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		children[1].Next()
+	}
+
+	if err := seq.Merge(children...); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if current, _ := seq.Current(); current != 260 {
+		t.Errorf("expected the high-water mark 260 got %d", current)
+	}
+
+	// Merging children that have not made progress does not move the parent
+	// backwards.
+	if err := seq.Merge(children[2], children[3]); err != nil {
+		t.Error(err.Error())
+	}
+
+	other, _ := New(2, 100, 2)
+	for _, bad := range [][]*Sequence{{other}, {new(Sequence)}} {
+		if err := seq.Merge(bad...); err == nil {
+			t.Error("merged a sequence that is not a partition")
+		}
+	}
+}
+
+// Test that the total remaining saturates.
+func TestTotalRemaining(t *testing.T) {
+	a, _ := New()
+	b, _ := NewAtomic()
+	c, _ := New(10)
+
+	if total := TotalRemaining(a, b, c); total != ^uint64(0) {
+		t.Errorf("expected saturated total got %d", total)
+	}
+
+	if total := TotalRemaining(); total != 0 {
+		t.Errorf("expected empty total got %d", total)
+	}
+}
@@ -0,0 +1,68 @@
+package sequence
+
+import "sync/atomic"
+
+// State describes where a sequence is in its lifecycle. A sequence moves from
+// Uninitialized to Ready when it is initialized, from Ready to Active when
+// Next issues its first value, and from Active to Exhausted when Next issues
+// its last value. Restart returns a sequence to Ready and Update may move it
+// to Active or Exhausted. Dump and Load preserve the state of a sequence.
+type State uint8
+
+// The states of a sequence.
+const (
+	Uninitialized State = iota // Init or Load has not been called
+	Ready                      // No values have been issued
+	Active                     // Values have been issued and more remain
+	Exhausted                  // The last value has been issued
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case Uninitialized:
+		return "uninitialized"
+	case Ready:
+		return "ready"
+	case Active:
+		return "active"
+	case Exhausted:
+		return "exhausted"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current state of the sequence.
+func (s *Sequence) State() State {
+	return state(s.initialized, s.current, s.increment, s.minvalue, s.maxvalue)
+}
+
+// State returns the current state of the sequence. It is done in an atomic
+// way, though the state may change as soon as it is returned.
+func (s *AtomicSequence) State() State {
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	return state(
+		s.initialized,
+		atomic.LoadUint64(&s.current),
+		atomic.LoadUint64(&s.increment),
+		atomic.LoadUint64(&s.minvalue),
+		atomic.LoadUint64(&s.maxvalue),
+	)
+}
+
+// Returns the state of a sequence with the given properties.
+func state(initialized bool, current, increment, minvalue, maxvalue uint64) State {
+	switch {
+	case !initialized:
+		return Uninitialized
+	case current < minvalue:
+		return Ready
+	case increment == 0 || remaining(current, increment, maxvalue) == 0:
+		return Exhausted
+	default:
+		return Active
+	}
+}
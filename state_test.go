@@ -0,0 +1,244 @@
+package sequence
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// Test the names of the states.
+func TestStateString(t *testing.T) {
+	tests := map[State]string{
+		Uninitialized: "uninitialized",
+		Ready:         "ready",
+		Active:        "active",
+		Exhausted:     "exhausted",
+		State(42):     "unknown",
+	}
+
+	for state, expected := range tests {
+		if s := state.String(); s != expected {
+			t.Errorf("expected %q got %q", expected, s)
+		}
+	}
+}
+
+// Test the transitions between states of a sequence.
+func TestStateTransitions(t *testing.T) {
+	seq := new(Sequence)
+	if seq.State() != Uninitialized || seq.IsStarted() {
+		t.Errorf("expected uninitialized sequence, got %s", seq.State())
+	}
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("uninitialized sequence issued a value")
+	}
+
+	seq.Init(2, 6, 2)
+	if seq.State() != Ready || seq.IsStarted() {
+		t.Errorf("expected ready sequence, got %s", seq.State())
+	}
+
+	seq.Next()
+	if seq.State() != Active || !seq.IsStarted() {
+		t.Errorf("expected active sequence, got %s", seq.State())
+	}
+
+	seq.Next()
+	seq.Next()
+	if seq.State() != Exhausted || !seq.IsStarted() {
+		t.Errorf("expected exhausted sequence, got %s", seq.State())
+	}
+
+	// The last value is still the current value of an exhausted sequence.
+	if val, err := seq.Current(); err != nil || val != 6 {
+		t.Errorf("expected current value 6, got %d (%v)", val, err)
+	}
+
+	// Next does not modify an exhausted sequence.
+	for i := 0; i < 3; i++ {
+		if _, err := seq.Next(); !errors.Is(err, ErrExhausted) {
+			t.Errorf("expected ErrExhausted, got %v", err)
+		}
+	}
+
+	if val, _ := seq.Current(); val != 6 {
+		t.Errorf("expected current value 6 after exhaustion, got %d", val)
+	}
+
+	seq.Restart()
+	if seq.State() != Ready {
+		t.Errorf("expected ready sequence after restart, got %s", seq.State())
+	}
+
+	seq.Update(4)
+	if seq.State() != Active {
+		t.Errorf("expected active sequence after update, got %s", seq.State())
+	}
+
+	if err := seq.Update(7); err == nil {
+		t.Error("updated the sequence beyond its maximum value")
+	}
+
+	seq.Update(6)
+	if seq.State() != Exhausted {
+		t.Errorf("expected exhausted sequence after update, got %s", seq.State())
+	}
+}
+
+// Test that a step that does not divide the range exhausts the sequence
+// before its maximum value.
+func TestStateUnevenStep(t *testing.T) {
+	seq, _ := New(3, 10, 3)
+	seq.Next()
+	seq.Next()
+
+	if seq.State() != Active {
+		t.Errorf("expected active sequence, got %s", seq.State())
+	}
+
+	seq.Next()
+	if seq.State() != Exhausted {
+		t.Errorf("expected exhausted sequence at 9, got %s", seq.State())
+	}
+}
+
+// Test that every state round trips through Dump and Load.
+func TestStateDumpLoad(t *testing.T) {
+	if _, err := new(Sequence).Dump(); err == nil {
+		t.Error("dumped an uninitialized sequence")
+	}
+
+	for _, n := range []int{0, 1, 3} {
+		seq, _ := New(3)
+		for i := 0; i < n; i++ {
+			seq.Next()
+		}
+
+		data, err := seq.Dump()
+		if err != nil {
+			t.Fatalf("could not dump %s sequence: %s", seq.State(), err)
+		}
+
+		loaded := new(Sequence)
+		if err := loaded.Load(data); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if loaded.State() != seq.State() {
+			t.Errorf("expected loaded sequence to be %s, got %s", seq.State(), loaded.State())
+		}
+
+		aloaded := new(AtomicSequence)
+		if err := aloaded.Load(data); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if aloaded.State() != seq.State() {
+			t.Errorf("expected atomic loaded sequence to be %s, got %s", seq.State(), aloaded.State())
+		}
+	}
+}
+
+// Test that every state of the big and shuffled sequences round trips
+// through Dump and Load.
+func TestStateDumpLoadTypes(t *testing.T) {
+	type dumper interface {
+		Dump() ([]byte, error)
+		Load([]byte) error
+		State() State
+		String() string
+	}
+
+	for _, n := range []int{0, 1, 3} {
+		bseq, _ := NewBig(big.NewInt(3))
+		sseq, _ := NewShuffled(42, 1, 3, 1)
+		for i := 0; i < n; i++ {
+			bseq.Next()
+			sseq.Next()
+		}
+
+		tests := []struct {
+			seq    dumper
+			loaded dumper
+		}{
+			{bseq, new(BigSequence)},
+			{sseq, new(ShuffledSequence)},
+		}
+
+		for _, tt := range tests {
+			data, err := tt.seq.Dump()
+			if err != nil {
+				t.Fatalf("could not dump %s sequence %s: %s", tt.seq.State(), tt.seq, err)
+			}
+
+			if err := tt.loaded.Load(data); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if tt.loaded.State() != tt.seq.State() || tt.loaded.String() != tt.seq.String() {
+				t.Errorf("expected loaded sequence to be %s, got %s", tt.seq, tt.loaded)
+			}
+		}
+
+		expected := []State{Ready, Active, Active, Exhausted}[n]
+		if bseq.State() != expected || sseq.State() != expected {
+			t.Errorf("expected %s sequences, got %s and %s", expected, bseq.State(), sseq.State())
+		}
+	}
+
+	if _, err := new(BigSequence).Dump(); err == nil {
+		t.Error("dumped an uninitialized big sequence")
+	}
+
+	if _, err := new(ShuffledSequence).Dump(); err == nil {
+		t.Error("dumped an uninitialized shuffled sequence")
+	}
+}
+
+// Test the transitions between states of an atomic sequence.
+func TestAtomicStateTransitions(t *testing.T) {
+	seq := new(AtomicSequence)
+	if seq.State() != Uninitialized {
+		t.Errorf("expected uninitialized sequence, got %s", seq.State())
+	}
+
+	seq.Init(2)
+	if seq.State() != Ready || seq.IsStarted() {
+		t.Errorf("expected ready sequence, got %s", seq.State())
+	}
+
+	seq.Next()
+	if seq.State() != Active || !seq.IsStarted() {
+		t.Errorf("expected active sequence, got %s", seq.State())
+	}
+
+	seq.Next()
+	if seq.State() != Exhausted || !seq.IsStarted() {
+		t.Errorf("expected exhausted sequence, got %s", seq.State())
+	}
+
+	if _, err := seq.Next(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got %v", err)
+	}
+
+	if val, err := seq.Current(); err != nil || val != 2 {
+		t.Errorf("expected current value 2, got %d (%v)", val, err)
+	}
+
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded := new(Sequence)
+	loaded.Load(data)
+	if loaded.State() != Exhausted {
+		t.Errorf("expected loaded sequence to be exhausted, got %s", loaded.State())
+	}
+
+	seq.Restart()
+	if seq.State() != Ready {
+		t.Errorf("expected ready sequence after restart, got %s", seq.State())
+	}
+}
@@ -0,0 +1,267 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+)
+
+// DefaultStripeBlock is the number of values a stripe of a StripedSequence
+// takes from the shared sequence at a time if no block size is specified.
+const DefaultStripeBlock = 1024
+
+// StripedSequence is a high-throughput sequence for values that must be
+// unique but need not be issued in order, e.g. ids of metrics or log events.
+// Rather than serializing every goroutine on a single counter, it splits
+// values into stripes, roughly one per processor, each of which takes a block
+// of values from a shared Sequence at a time and hands them out under its own
+// lock. Goroutines only contend on the shared sequence once per block.
+//
+// Values are unique and each stripe issues its values in increasing order,
+// but values from different stripes are interleaved arbitrarily, so a value
+// may be issued after a larger one. Operations that modify the shared
+// sequence, such as Restart, Update and Dump, discard the values remaining in
+// the stripes; those values are never issued. A StripedSequence is safe for
+// concurrent use, though it must not be used concurrently with Init or Load.
+type StripedSequence struct {
+	mu      sync.Mutex // Protects the shared sequence
+	shared  Sequence   // The sequence blocks are taken from
+	block   uint64     // The number of values taken by a stripe at a time
+	stripes []stripe   // Blocks of values being issued
+}
+
+// A block of values that is being issued, padded to avoid false sharing
+// between the stripes of a sequence.
+type stripe struct {
+	mu        sync.Mutex
+	next      uint64 // The next value to issue
+	step      uint64 // The step between values
+	remaining uint64 // The number of values left in the block
+	_         [96]byte
+}
+
+// NewStriped constructs a StripedSequence whose stripes take block values at
+// a time (DefaultStripeBlock if zero) from a shared sequence specified by the
+// params, which are interpreted as by Sequence.Init.
+func NewStriped(block uint64, params ...uint64) (*StripedSequence, error) {
+	seq := &StripedSequence{block: block}
+	err := seq.Init(params...)
+	return seq, err
+}
+
+// Init the shared sequence as described by Sequence.Init.
+func (s *StripedSequence) Init(params ...uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.shared.Init(params...); err != nil {
+		return err
+	}
+	s.init()
+	return nil
+}
+
+// Create the stripes. Must be called with the lock.
+func (s *StripedSequence) init() {
+	if s.block == 0 {
+		s.block = DefaultStripeBlock
+	}
+
+	// Use a power of two stripes so that a stripe can be picked with a mask.
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	s.stripes = make([]stripe, n)
+}
+
+// Lock and return a stripe, preferring a stripe no other goroutine holds.
+func (s *StripedSequence) stripe() *stripe {
+	mask := uint64(len(s.stripes) - 1)
+	start := rand.Uint64()
+	for i := uint64(0); i <= mask; i++ {
+		if st := &s.stripes[(start+i)&mask]; st.mu.TryLock() {
+			return st
+		}
+	}
+
+	st := &s.stripes[start&mask]
+	st.mu.Lock()
+	return st
+}
+
+// Next returns a value that has not been issued before, or an error if every
+// value of the shared sequence has been issued.
+func (s *StripedSequence) Next() (uint64, error) {
+	if len(s.stripes) == 0 {
+		return 0, errors.New("sequence has not been initialized")
+	}
+
+	st := s.stripe()
+	defer st.mu.Unlock()
+
+	if st.remaining == 0 {
+		if err := s.refill(st); err != nil {
+			return 0, err
+		}
+	}
+
+	val := st.next
+	st.next += st.step
+	st.remaining--
+	return val, nil
+}
+
+// Take a block of values from the shared sequence for the locked stripe.
+func (s *StripedSequence) refill(st *stripe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.block
+	if rem := s.shared.Remaining(); rem < n {
+		if rem == 0 {
+			return fmt.Errorf("%w: reached maximum bound of sequence", ErrExhausted)
+		}
+		n = rem
+	}
+
+	first, _, err := s.shared.reserve(n)
+	if err != nil {
+		return err
+	}
+
+	st.next, st.step, st.remaining = first, s.shared.increment, n
+	return nil
+}
+
+// NextContext returns the next value unless the context is done.
+func (s *StripedSequence) NextContext(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, WrapContextError(err)
+	}
+	return s.Next()
+}
+
+// ReserveContext reserves n consecutive values directly from the shared
+// sequence unless the context is done. Either all n values are reserved or
+// an error is returned.
+func (s *StripedSequence) ReserveContext(ctx context.Context, n uint64) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, WrapContextError(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	first, last, err := s.shared.reserve(n)
+	if err != nil {
+		return nil, err
+	}
+	return reserved(first, last, s.shared.increment), nil
+}
+
+// Lock every stripe, discarding their remaining values, and the shared
+// sequence, returning a function that unlocks them all.
+func (s *StripedSequence) drain() func() {
+	for i := range s.stripes {
+		s.stripes[i].mu.Lock()
+		s.stripes[i].remaining = 0
+	}
+	s.mu.Lock()
+
+	return func() {
+		s.mu.Unlock()
+		for i := range s.stripes {
+			s.stripes[i].mu.Unlock()
+		}
+	}
+}
+
+// Restart the shared sequence, discarding the values in the stripes.
+func (s *StripedSequence) Restart() error {
+	defer s.drain()()
+	return s.shared.Restart()
+}
+
+// Update the shared sequence, discarding the values in the stripes so that
+// no value less than or equal to val is issued afterwards.
+func (s *StripedSequence) Update(val uint64) error {
+	defer s.drain()()
+	return s.shared.Update(val)
+}
+
+// Current returns the last value taken from the shared sequence by a stripe.
+// Because stripes issue their blocks independently, values less than the
+// current value may not have been issued yet.
+func (s *StripedSequence) Current() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shared.Current()
+}
+
+// IsStarted returns true if the shared sequence has been started or if any
+// stripe still has values to issue.
+func (s *StripedSequence) IsStarted() bool {
+	s.mu.Lock()
+	started := s.shared.IsStarted()
+	s.mu.Unlock()
+
+	return started || s.buffered() > 0
+}
+
+// Returns the number of values remaining in the stripes.
+func (s *StripedSequence) buffered() (n uint64) {
+	for i := range s.stripes {
+		s.stripes[i].mu.Lock()
+		n += s.stripes[i].remaining
+		s.stripes[i].mu.Unlock()
+	}
+	return n
+}
+
+// Bounds returns the range of the shared sequence.
+func (s *StripedSequence) Bounds() (minvalue, maxvalue, increment uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shared.Bounds()
+}
+
+// Remaining returns the number of values that can still be issued by the
+// stripes and the shared sequence.
+func (s *StripedSequence) Remaining() uint64 {
+	buffered := s.buffered()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shared.Remaining() + buffered
+}
+
+// String returns a human readable representation of the sequence.
+func (s *StripedSequence) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("%s in %d stripes of %d values", s.shared.String(), len(s.stripes), s.block)
+}
+
+// Dump the shared sequence as described by Sequence.Dump, discarding the
+// values remaining in the stripes so that they are not issued by both this
+// sequence and a sequence the data is loaded into.
+func (s *StripedSequence) Dump() ([]byte, error) {
+	defer s.drain()()
+	return s.shared.Dump()
+}
+
+// Load the shared sequence from data exported by Dump.
+func (s *StripedSequence) Load(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.shared.Load(data); err != nil {
+		return err
+	}
+	s.init()
+	return nil
+}
@@ -0,0 +1,191 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// Ensure that the striped sequence implements the sequence interfaces.
+func TestStripedInterface(t *testing.T) {
+	var _ Incrementer = &StripedSequence{}
+	var _ ContextIncrementer = &StripedSequence{}
+	var _ Bounded = &StripedSequence{}
+
+	if size := unsafe.Sizeof(stripe{}); size%64 != 0 {
+		t.Errorf("stripes are %d bytes and may share cache lines", size)
+	}
+}
+
+// Test that every value is issued exactly once by concurrent goroutines.
+func TestStripedConcurrent(t *testing.T) {
+	seq, err := NewStriped(100, 10007)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		seen = make(map[uint64]bool)
+	)
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var vals []uint64
+			for {
+				val, err := seq.Next()
+				if err != nil {
+					if !errors.Is(err, ErrExhausted) {
+						t.Error(err.Error())
+					}
+					break
+				}
+				vals = append(vals, val)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, val := range vals {
+				if seen[val] {
+					t.Errorf("value %d issued twice", val)
+				}
+				seen[val] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 10007 {
+		t.Errorf("expected 10007 values got %d", len(seen))
+	}
+
+	if seq.Remaining() != 0 || !seq.IsStarted() {
+		t.Errorf("expected exhausted sequence got %s", seq)
+	}
+}
+
+// Test the state of a striped sequence with a single goroutine.
+func TestStripedState(t *testing.T) {
+	seq, _ := NewStriped(10, 2, 200, 2)
+	if seq.IsStarted() {
+		t.Error("new sequence is started")
+	}
+
+	if minvalue, maxvalue, increment := seq.Bounds(); minvalue != 2 || maxvalue != 200 || increment != 2 {
+		t.Errorf("unexpected bounds %d %d %d", minvalue, maxvalue, increment)
+	}
+
+	val, err := seq.Next()
+	if err != nil || val != 2 {
+		t.Fatalf("expected 2 got %d (%v)", val, err)
+	}
+
+	// A block of 10 values was taken from the shared sequence.
+	if current, _ := seq.Current(); current != 20 {
+		t.Errorf("expected the shared sequence at 20 got %d", current)
+	}
+
+	if !seq.IsStarted() || seq.Remaining() != 99 {
+		t.Errorf("expected 99 values remaining got %d", seq.Remaining())
+	}
+
+	// Reservations are taken directly from the shared sequence.
+	vals, err := seq.ReserveContext(context.Background(), 3)
+	if err != nil || len(vals) != 3 || vals[0] != 22 || vals[2] != 26 {
+		t.Errorf("unexpected reservation %v (%v)", vals, err)
+	}
+
+	// Updating discards the values in the stripes.
+	if err := seq.Update(100); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if seq.Remaining() != 50 {
+		t.Errorf("expected 50 values remaining got %d", seq.Remaining())
+	}
+
+	if val, _ := seq.Next(); val != 102 {
+		t.Errorf("expected 102 after update got %d", val)
+	}
+
+	if err := seq.Restart(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 2 {
+		t.Errorf("expected 2 after restart got %d", val)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := seq.NextContext(ctx); !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected canceled error got %v", err)
+	}
+}
+
+// Test that values buffered in stripes are not issued after a dump.
+func TestStripedDumpLoad(t *testing.T) {
+	seq, _ := NewStriped(100)
+	for i := 0; i < 10; i++ {
+		seq.Next()
+	}
+
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded := new(StripedSequence)
+	if err := loaded.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	a, _ := seq.Next()
+	b, _ := loaded.Next()
+	if a != 101 || b != 101 {
+		t.Errorf("expected both sequences to continue from 101 got %d and %d", a, b)
+	}
+
+	if _, err := new(StripedSequence).Next(); err == nil {
+		t.Error("uninitialized sequence issued a value")
+	}
+}
+
+//===========================================================================
+// Benchmarks
+//===========================================================================
+
+func BenchmarkParallelAtomic(b *testing.B) {
+	seq, err := NewAtomic()
+	if err != nil {
+		b.Error(err.Error())
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			seq.Next()
+		}
+	})
+	b.ReportAllocs()
+}
+
+func BenchmarkParallelStriped(b *testing.B) {
+	seq, err := NewStriped(0)
+	if err != nil {
+		b.Error(err.Error())
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			seq.Next()
+		}
+	})
+	b.ReportAllocs()
+}
@@ -0,0 +1,181 @@
+package sequence
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Ensure that the synchronized sequence implements the sequence interfaces.
+func TestInterfaceSynchronized(t *testing.T) {
+	var _ Incrementer = &SynchronizedSequence{}
+	var _ ContextIncrementer = &SynchronizedSequence{}
+}
+
+// Test that a sequence that is not safe for concurrent use can be shared
+// between goroutines once synchronized; run with -race.
+func TestSynchronizedConcurrency(t *testing.T) {
+	seq := Synchronized(new(Sequence))
+	if err := seq.Init(1000); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var wg sync.WaitGroup
+	vals := make(chan uint64, 1000)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				val, err := seq.Next()
+				if err != nil {
+					t.Error(err.Error())
+					return
+				}
+				vals <- val
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				seq.Current()
+				seq.IsStarted()
+				_ = seq.String()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(vals)
+
+	seen := make(map[uint64]struct{})
+	for val := range vals {
+		if _, ok := seen[val]; ok {
+			t.Fatalf("value %d was issued twice", val)
+		}
+		seen[val] = struct{}{}
+	}
+
+	if len(seen) != 1000 {
+		t.Errorf("expected 1000 unique values got %d", len(seen))
+	}
+
+	if _, err := seq.Next(); err == nil {
+		t.Error("synchronized sequence issued a value beyond its maximum")
+	}
+}
+
+// An Incrementer whose Current waits until a number of callers are inside it.
+type barrierSequence struct {
+	Sequence
+	barrier sync.WaitGroup
+}
+
+func (s *barrierSequence) Current() (uint64, error) {
+	s.barrier.Done()
+	s.barrier.Wait()
+	return s.Sequence.Current()
+}
+
+// Test that readers of a synchronized sequence do not exclude each other.
+func TestSynchronizedReaders(t *testing.T) {
+	inc := new(barrierSequence)
+	inc.barrier.Add(2)
+	seq := Synchronized(inc)
+	seq.Init()
+	seq.Next()
+
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				seq.Current()
+			}()
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("concurrent calls to Current excluded each other")
+	}
+}
+
+// Test that reservations through a synchronized sequence are consecutive even
+// though the wrapped Incrementer does not implement ContextIncrementer.
+func TestSynchronizedReserve(t *testing.T) {
+	seq := Synchronized(struct{ Incrementer }{new(Sequence)})
+	seq.Init()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vals, err := seq.ReserveContext(context.Background(), 10)
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+
+			for j := 1; j < len(vals); j++ {
+				if vals[j] != vals[j-1]+1 {
+					t.Errorf("reservation %v is not consecutive", vals)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := seq.NextContext(ctx); err == nil {
+		t.Error("issued a value with a canceled context")
+	}
+
+	if val, _ := seq.Current(); val != 80 {
+		t.Errorf("expected current value 80 got %d", val)
+	}
+}
+
+// Test that the synchronized sequence passes state through to the underlying
+// sequence.
+func TestSynchronizedState(t *testing.T) {
+	seq := Synchronized(new(Sequence))
+	seq.Init(10)
+	seq.Next()
+
+	if err := seq.Update(5); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if seq.String() != "Sequence at 5, incremented by 1 between 1 and 10" {
+		t.Errorf("unexpected string %q", seq.String())
+	}
+
+	data, err := seq.Dump()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded := Synchronized(new(Sequence))
+	if err := loaded.Load(data); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := loaded.Next(); val != 6 {
+		t.Errorf("expected 6 after load got %d", val)
+	}
+
+	if err := seq.Restart(); err != nil || seq.IsStarted() {
+		t.Errorf("restart was not successful: %v", err)
+	}
+}
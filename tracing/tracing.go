@@ -0,0 +1,94 @@
+// Package tracing instruments sequences with OpenTelemetry spans, so that the
+// calls made to a sequence appear in the traces of the requests that use it.
+// Any sequence.Incrementer can be traced by chaining it with the middleware:
+//
+//     base, err := sequence.New()
+//     seq := sequence.Chain(base, tracing.Middleware(nil))
+//     idx, err := seq.NextContext(ctx)
+//
+// A span named after the method, e.g. "sequence.next", is started for every
+// intercepted call as a child of the span in the context of the call, so the
+// context-aware methods should be used to connect the spans to a trace. The
+// spans are annotated with the following attributes where applicable:
+//
+//     sequence.value   the value returned by Next or passed to Update
+//     sequence.count   the number of values requested from ReserveContext
+//
+// Values that do not fit in an int64 attribute are recorded as strings.
+//
+// Failed calls record the error on the span and set its status to error.
+package tracing
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/bbengfort/sequence"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the default tracer.
+const ScopeName = "github.com/bbengfort/sequence/tracing"
+
+// The attributes that annotate the spans.
+const (
+	valueKey = attribute.Key("sequence.value")
+	countKey = attribute.Key("sequence.count")
+)
+
+// The context key of the span started by a middleware, unique to each
+// middleware so that the span is found even if later middleware replaces the
+// context of the call with a child context.
+type spanKey struct{ _ byte }
+
+// Returns a uint64 attribute, as a string if it does not fit in an int64.
+func uint64Value(key attribute.Key, val uint64) attribute.KeyValue {
+	if val > math.MaxInt64 {
+		return key.String(strconv.FormatUint(val, 10))
+	}
+	return key.Int64(int64(val))
+}
+
+// Middleware returns sequence middleware that starts a span for every
+// intercepted call with the tracer, or with a tracer from the global
+// TracerProvider if the tracer is nil.
+func Middleware(tracer trace.Tracer) sequence.Middleware {
+	if tracer == nil {
+		tracer = otel.Tracer(ScopeName)
+	}
+
+	key := new(spanKey)
+	return sequence.Middleware{
+		Before: func(call *sequence.Call) error {
+			opts := []trace.SpanStartOption{trace.WithTimestamp(call.Start)}
+			switch call.Method {
+			case sequence.MethodReserve:
+				opts = append(opts, trace.WithAttributes(uint64Value(countKey, call.Arg)))
+			case sequence.MethodUpdate:
+				opts = append(opts, trace.WithAttributes(uint64Value(valueKey, call.Arg)))
+			}
+
+			ctx, span := tracer.Start(call.Context, "sequence."+string(call.Method), opts...)
+			call.Context = context.WithValue(ctx, key, span)
+			return nil
+		},
+		After: func(call *sequence.Call) {
+			span, ok := call.Context.Value(key).(trace.Span)
+			if !ok {
+				return
+			}
+
+			if call.Err != nil {
+				span.RecordError(call.Err)
+				span.SetStatus(codes.Error, call.Err.Error())
+			} else if call.Method == sequence.MethodNext {
+				span.SetAttributes(uint64Value(valueKey, call.Value))
+			}
+			span.End()
+		},
+	}
+}
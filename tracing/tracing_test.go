@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bbengfort/sequence"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Returns a tracer whose ended spans are recorded.
+func newRecorder() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+// Test that spans are recorded for the intercepted calls.
+func TestMiddleware(t *testing.T) {
+	recorder, provider := newRecorder()
+	tracer := provider.Tracer(ScopeName)
+
+	base, _ := sequence.New(1)
+	seq := sequence.Chain(base, Middleware(tracer))
+
+	ctx, parent := tracer.Start(context.Background(), "request")
+	seq.NextContext(ctx)
+	seq.NextContext(ctx)
+	parent.End()
+
+	seq.Update(1)
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans got %d", len(spans))
+	}
+
+	for i, name := range []string{"sequence.next", "sequence.next", "request", "sequence.update"} {
+		if spans[i].Name() != name {
+			t.Errorf("expected span %d to be %s got %s", i, name, spans[i].Name())
+		}
+	}
+
+	// The spans of calls with a context are children of the span in the context.
+	for _, span := range spans[:2] {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the request", span.Name())
+		}
+	}
+
+	if spans[3].Parent().IsValid() {
+		t.Error("span of a call without a context has a parent")
+	}
+
+	if attrs := spans[0].Attributes(); len(attrs) != 1 || attrs[0] != valueKey.Int64(1) {
+		t.Errorf("unexpected attributes of next %v", attrs)
+	}
+
+	if spans[1].Status().Code != codes.Error || len(spans[1].Events()) != 1 {
+		t.Errorf("expected exhausted error to be recorded, got status %v", spans[1].Status())
+	}
+
+	if attrs := spans[3].Attributes(); len(attrs) != 1 || attrs[0] != valueKey.Int64(1) {
+		t.Errorf("unexpected attributes of update %v", attrs)
+	}
+}
+
+// Test that each middleware ends its own span when several are chained and
+// that spans are ended when later middleware aborts the call.
+func TestMiddlewareNested(t *testing.T) {
+	recorder, provider := newRecorder()
+	outer := provider.Tracer("outer")
+	inner := provider.Tracer("inner")
+
+	fault := errors.New("injected fault")
+	base, _ := sequence.New(sequence.MaximumBound)
+	seq := sequence.Chain(base,
+		Middleware(outer),
+		Middleware(inner),
+		sequence.Middleware{Before: func(call *sequence.Call) error { return fault }},
+	)
+
+	if _, err := seq.ReserveContext(context.Background(), sequence.MaximumBound); !errors.Is(err, fault) {
+		t.Fatalf("expected injected fault got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got %d", len(spans))
+	}
+
+	if spans[0].InstrumentationScope().Name != "inner" || spans[1].InstrumentationScope().Name != "outer" {
+		t.Errorf("spans were not ended in order")
+	}
+
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Error("inner span is not a child of the outer span")
+	}
+
+	expected := attribute.String("sequence.count", "18446744073709551614")
+	for _, span := range spans {
+		if attrs := span.Attributes(); len(attrs) != 1 || attrs[0] != expected {
+			t.Errorf("unexpected attributes of reserve %v", attrs)
+		}
+
+		if span.Status().Code != codes.Error {
+			t.Errorf("expected fault to be recorded on %s", span.InstrumentationScope().Name)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Transaction errors
+var (
+	ErrTxDone     = errors.New("transaction has already been committed or rolled back")
+	ErrTxConflict = errors.New("sequence was advanced outside of the transaction")
+)
+
+// Tx takes values from a sequence that can be given back with Rollback, e.g.
+// if the database write that an id was taken for fails. Values can only be
+// given back if no other values have been issued by the sequence since the
+// first value of the transaction, since the values after them could not be
+// given back without issuing those values twice. If the sequence was advanced
+// in the meantime, by Next or by another transaction, Rollback returns
+// ErrTxConflict and the values of the transaction are never issued again.
+//
+// Concurrent transactions on an AtomicSequence therefore roll back safely in
+// reverse order: the transaction that took the last values can roll back,
+// after which the transaction that took the values before it can too. A Tx
+// is not itself safe for concurrent use.
+type Tx struct {
+	seq    transactional // The sequence values are taken from
+	values []uint64      // The values taken by the transaction
+	start  uint64        // The current value before the first value was taken
+	last   uint64        // The current value after the last value was taken
+	clean  bool          // False if the sequence was advanced between values
+	done   bool          // Set by Commit and Rollback
+}
+
+// The operations a transaction requires of a sequence.
+type transactional interface {
+	advance() (prev, val uint64, err error)
+	rewind(from, to uint64, actor string) error
+}
+
+// Begin a transaction that takes values from the sequence. Like the sequence,
+// the transaction is not safe for concurrent use.
+func (s *Sequence) Begin() (*Tx, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+	return &Tx{seq: s, clean: true}, nil
+}
+
+// Begin a transaction that takes values from the sequence. Other goroutines
+// may take values from the sequence or begin their own transactions while
+// the transaction is in progress, as described by Tx.
+func (s *AtomicSequence) Begin() (*Tx, error) {
+	if !s.initialized {
+		return nil, errors.New("sequence has not been initialized")
+	}
+	return &Tx{seq: s, clean: true}, nil
+}
+
+// Next takes the next value of the sequence. Unlike Sequence.Next, the state
+// of the sequence is not modified if an error is returned.
+func (tx *Tx) Next() (uint64, error) {
+	if tx.done {
+		return 0, ErrTxDone
+	}
+
+	prev, val, err := tx.seq.advance()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(tx.values) == 0 {
+		tx.start = prev
+	} else if prev != tx.last {
+		tx.clean = false
+	}
+
+	tx.last = val
+	tx.values = append(tx.values, val)
+	return val, nil
+}
+
+// Values returns the values taken by the transaction in the order they were
+// taken.
+func (tx *Tx) Values() []uint64 {
+	return append([]uint64(nil), tx.values...)
+}
+
+// Commit the transaction, keeping the values it has taken.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	return nil
+}
+
+// Rollback the transaction, giving its values back to the sequence so that
+// they are issued again. If other values have been issued since the first
+// value of the transaction, ErrTxConflict is returned and the values are not
+// given back. Either way the transaction is finished.
+func (tx *Tx) Rollback() error {
+	return tx.RollbackAs("")
+}
+
+// RollbackAs rolls back the transaction as described by Rollback, attributing
+// the audit event to the actor instead of the actor given to SetAudit unless
+// it is empty.
+func (tx *Tx) RollbackAs(actor string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	if len(tx.values) == 0 {
+		return nil
+	}
+
+	if !tx.clean {
+		return fmt.Errorf("%w: cannot give back %d values", ErrTxConflict, len(tx.values))
+	}
+	return tx.seq.rewind(tx.last, tx.start, actor)
+}
+
+// Take the next value without modifying the sequence on error.
+func (s *Sequence) advance() (prev, val uint64, err error) {
+	prev = s.current
+	if val, _, err = s.reserve(1); err != nil {
+		return 0, 0, err
+	}
+	return prev, val, nil
+}
+
+// Set current back to the value before the transaction if it is unchanged.
+func (s *Sequence) rewind(from, to uint64, actor string) error {
+	if s.current != from {
+		return fmt.Errorf("%w: current value is %d, not %d", ErrTxConflict, s.current, from)
+	}
+
+	s.current = to
+	return s.record(AuditRollback, actor, from, to)
+}
+
+// Take the next value without modifying the sequence on error.
+func (s *AtomicSequence) advance() (prev, val uint64, err error) {
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	for {
+		current := atomic.LoadUint64(&s.current)
+
+		next, ok := stepForward(current, 1, atomic.LoadUint64(&s.increment))
+		if !ok || next > atomic.LoadUint64(&s.maxvalue) {
+			return 0, 0, fmt.Errorf("%w: reached maximum bound of sequence", ErrExhausted)
+		}
+
+		if atomic.CompareAndSwapUint64(&s.current, current, next) {
+			return current, next, nil
+		}
+	}
+}
+
+// Set current back to the value before the transaction if it is unchanged.
+func (s *AtomicSequence) rewind(from, to uint64, actor string) error {
+	s.alter.RLock()
+	defer s.alter.RUnlock()
+
+	if !atomic.CompareAndSwapUint64(&s.current, from, to) {
+		return fmt.Errorf("%w: current value is %d, not %d", ErrTxConflict, atomic.LoadUint64(&s.current), from)
+	}
+	return (*Sequence)(s).record(AuditRollback, actor, from, to)
+}
@@ -0,0 +1,221 @@
+package sequence
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// Test that committed values are kept.
+func TestTxCommit(t *testing.T) {
+	seq, _ := New()
+	tx, err := seq.Begin()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		if val, err := tx.Next(); err != nil || val != i {
+			t.Errorf("expected value %d, got %d (%v)", i, val, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 4 {
+		t.Errorf("expected next value 4 after commit, got %d", val)
+	}
+
+	if _, err := tx.Next(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("expected ErrTxDone from a committed transaction, got %v", err)
+	}
+
+	if err := tx.Rollback(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("expected ErrTxDone rolling back a committed transaction, got %v", err)
+	}
+
+	if _, err := new(Sequence).Begin(); err == nil {
+		t.Error("began a transaction on an uninitialized sequence")
+	}
+}
+
+// Test that values are given back by a rollback.
+func TestTxRollback(t *testing.T) {
+	seq, _ := New()
+	seq.Next()
+
+	tx, _ := seq.Begin()
+	tx.Next()
+	tx.Next()
+
+	if vals := tx.Values(); len(vals) != 2 || vals[0] != 2 || vals[1] != 3 {
+		t.Errorf("unexpected transaction values %v", vals)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 2 {
+		t.Errorf("expected value 2 to be issued again, got %d", val)
+	}
+
+	// Rolling back an empty transaction does nothing.
+	tx, _ = seq.Begin()
+	if err := tx.Rollback(); err != nil {
+		t.Error(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 3 {
+		t.Errorf("expected next value 3, got %d", val)
+	}
+}
+
+// Test that values are not given back if the sequence was advanced.
+func TestTxRollbackConflict(t *testing.T) {
+	seq, _ := New()
+
+	// Advanced after the transaction.
+	tx, _ := seq.Begin()
+	tx.Next()
+	seq.Next()
+
+	if err := tx.Rollback(); !errors.Is(err, ErrTxConflict) {
+		t.Errorf("expected ErrTxConflict, got %v", err)
+	}
+
+	// Advanced between the values of the transaction.
+	tx, _ = seq.Begin()
+	tx.Next()
+	seq.Next()
+	tx.Next()
+
+	if err := tx.Rollback(); !errors.Is(err, ErrTxConflict) {
+		t.Errorf("expected ErrTxConflict, got %v", err)
+	}
+
+	if val, _ := seq.Next(); val != 6 {
+		t.Errorf("expected no values to be given back, got next value %d", val)
+	}
+}
+
+// Test that a failed Next in a transaction does not modify the sequence.
+func TestTxExhausted(t *testing.T) {
+	seq, _ := New(2)
+	tx, _ := seq.Begin()
+	tx.Next()
+	tx.Next()
+
+	if _, err := tx.Next(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, err := seq.Next(); err != nil || val != 1 {
+		t.Errorf("expected value 1 to be issued again, got %d (%v)", val, err)
+	}
+}
+
+// Test that rollbacks are audited.
+func TestTxAudit(t *testing.T) {
+	sink := new(memorySink)
+	seq, _ := New()
+	seq.SetAudit(sink, "")
+
+	tx, _ := seq.Begin()
+	tx.Next()
+	tx.Next()
+	tx.Rollback()
+
+	if len(sink.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(sink.events))
+	}
+
+	if event := sink.events[0]; event.Op != AuditRollback || event.Old != 2 || event.New != 0 {
+		t.Errorf("unexpected rollback event %+v", event)
+	}
+}
+
+// Test that concurrent transactions on an atomic sequence roll back in
+// reverse order.
+func TestAtomicTx(t *testing.T) {
+	seq, _ := NewAtomic()
+	tx1, _ := seq.Begin()
+	tx2, _ := seq.Begin()
+
+	tx1.Next()
+	tx2.Next()
+	tx2.Next()
+
+	if err := tx1.Rollback(); !errors.Is(err, ErrTxConflict) {
+		t.Errorf("expected ErrTxConflict rolling back the earlier transaction, got %v", err)
+	}
+
+	if err := tx2.Rollback(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 2 {
+		t.Errorf("expected value 2 to be issued again, got %d", val)
+	}
+
+	tx3, _ := seq.Begin()
+	tx4, _ := seq.Begin()
+	tx3.Next()
+	tx4.Next()
+
+	if err := tx4.Rollback(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := tx3.Rollback(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val, _ := seq.Next(); val != 3 {
+		t.Errorf("expected value 3 to be issued again, got %d", val)
+	}
+}
+
+// Test that values kept by concurrent transactions are never issued twice.
+func TestAtomicTxConcurrent(t *testing.T) {
+	seq, _ := NewAtomic()
+
+	var wg sync.WaitGroup
+	results := make([][]uint64, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				tx, _ := seq.Begin()
+				tx.Next()
+				tx.Next()
+
+				if j%2 == 0 {
+					tx.Rollback()
+					continue
+				}
+
+				tx.Commit()
+				results[i] = append(results[i], tx.Values()...)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for _, vals := range results {
+		for _, val := range vals {
+			if seen[val] {
+				t.Fatalf("value %d was issued twice", val)
+			}
+			seen[val] = true
+		}
+	}
+}
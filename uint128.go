@@ -0,0 +1,58 @@
+package sequence
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// uint128 is an unsigned 128-bit integer used as the fast path of the
+// BigSequence, avoiding math/big allocations for values that fit in 128 bits.
+type uint128 struct {
+	hi uint64 // The most significant 64 bits
+	lo uint64 // The least significant 64 bits
+}
+
+// Returns the uint128 representation of b, or false if b is negative or does
+// not fit in 128 bits.
+func toUint128(b *big.Int) (uint128, bool) {
+	if b.Sign() < 0 || b.BitLen() > 128 {
+		return uint128{}, false
+	}
+
+	buf := b.FillBytes(make([]byte, 16))
+	return uint128{hi: binary.BigEndian.Uint64(buf[:8]), lo: binary.BigEndian.Uint64(buf[8:])}, true
+}
+
+// Returns a + b and true if the addition overflowed 128 bits.
+func (a uint128) add(b uint128) (uint128, bool) {
+	lo, carry := bits.Add64(a.lo, b.lo, 0)
+	hi, carry := bits.Add64(a.hi, b.hi, carry)
+	return uint128{hi: hi, lo: lo}, carry != 0
+}
+
+// Returns a - b and true if the subtraction underflowed.
+func (a uint128) sub(b uint128) (uint128, bool) {
+	lo, borrow := bits.Sub64(a.lo, b.lo, 0)
+	hi, borrow := bits.Sub64(a.hi, b.hi, borrow)
+	return uint128{hi: hi, lo: lo}, borrow != 0
+}
+
+// Returns -1, 0 or +1 if a is less than, equal to or greater than b.
+func (a uint128) cmp(b uint128) int {
+	switch {
+	case a.hi < b.hi || (a.hi == b.hi && a.lo < b.lo):
+		return -1
+	case a.hi == b.hi && a.lo == b.lo:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Returns the math/big representation of a.
+func (a uint128) big() *big.Int {
+	b := new(big.Int).SetUint64(a.hi)
+	b.Lsh(b, 64)
+	return b.Or(b, new(big.Int).SetUint64(a.lo))
+}
@@ -0,0 +1,125 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Invariant errors are wrapped by an InvariantError when serialized state
+// cannot be loaded because it violates one of the invariants of a sequence.
+var (
+	ErrMissingField = errors.New("field is missing")
+	ErrUnknownField = errors.New("field is not part of the sequence format")
+	ErrZeroStep     = errors.New("step must not be zero")
+	ErrOutOfBounds  = errors.New("value is out of bounds")
+	ErrEmptyRange   = errors.New("minimum value must be less than or equal to the maximum value")
+	ErrStepTooLarge = errors.New("step must be less than or equal to the minimum value")
+	ErrOutOfRange   = errors.New("current value is outside of the range of the sequence")
+)
+
+// InvariantError is returned by Load when serialized state violates one of
+// the invariants that Init enforces, e.g. because it was corrupted or
+// hand-edited, and identifies the field of the serialized state at fault.
+// Use errors.Is with the invariant errors to tell which invariant failed.
+type InvariantError struct {
+	Field string // The serialized field, e.g. "increment"
+	Err   error  // The invariant that was violated, e.g. ErrZeroStep
+}
+
+// Error returns the field and the invariant that was violated.
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invalid sequence %s: %s", e.Field, e.Err)
+}
+
+// Unwrap returns the invariant that was violated.
+func (e *InvariantError) Unwrap() error {
+	return e.Err
+}
+
+// The fields of the serialized state of a sequence.
+var stateFields = []string{"current", "increment", "minvalue", "maxvalue"}
+
+// Decode the fields of serialized state, returning an InvariantError if a
+// field is missing or, in strict mode, if there are any unknown fields.
+func decodeFields[T any](data []byte, strict bool, fields []string) (map[string]T, error) {
+	vals := make(map[string]T)
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if _, ok := vals[field]; !ok {
+			return nil, &InvariantError{Field: field, Err: ErrMissingField}
+		}
+	}
+
+	if strict && len(vals) > len(fields) {
+		unknown := make([]string, 0, len(vals)-len(fields))
+		for field := range vals {
+			if !slices.Contains(fields, field) {
+				unknown = append(unknown, field)
+			}
+		}
+
+		slices.Sort(unknown)
+		return nil, &InvariantError{Field: unknown[0], Err: ErrUnknownField}
+	}
+
+	return vals, nil
+}
+
+// Parse the serialized state of a sequence exported by Dump into an
+// initialized sequence, validating it as described by Sequence.Load.
+func parse(data []byte, strict bool) (*Sequence, error) {
+	vals, err := decodeFields[uint64](data, strict, stateFields)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sequence{
+		current:     vals["current"],
+		increment:   vals["increment"],
+		minvalue:    vals["minvalue"],
+		maxvalue:    vals["maxvalue"],
+		initialized: true,
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate the invariants that Init establishes and that every other method
+// maintains: the step is not zero and not greater than the minimum value, the
+// range is within the bounds and not empty, and the current value is at most
+// one step before the minimum value and no greater than the maximum value.
+func (s *Sequence) validate() error {
+	if s.increment == 0 {
+		return &InvariantError{Field: "increment", Err: ErrZeroStep}
+	}
+
+	if s.minvalue < MinimumBound {
+		return &InvariantError{Field: "minvalue", Err: ErrOutOfBounds}
+	}
+
+	if s.maxvalue > MaximumBound {
+		return &InvariantError{Field: "maxvalue", Err: ErrOutOfBounds}
+	}
+
+	if s.minvalue > s.maxvalue {
+		return &InvariantError{Field: "maxvalue", Err: ErrEmptyRange}
+	}
+
+	first, ok := stepBefore(s.minvalue, s.increment)
+	if !ok {
+		return &InvariantError{Field: "increment", Err: ErrStepTooLarge}
+	}
+
+	if s.current < first || s.current > s.maxvalue {
+		return &InvariantError{Field: "current", Err: ErrOutOfRange}
+	}
+	return nil
+}
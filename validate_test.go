@@ -0,0 +1,193 @@
+package sequence
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test that state violating the invariants of a sequence is not loaded.
+func TestLoadInvariants(t *testing.T) {
+	tests := []struct {
+		data  string
+		field string
+		err   error
+	}{
+		{`{"increment":1,"minvalue":1,"maxvalue":10}`, "current", ErrMissingField},
+		{`{"current":1,"minvalue":1,"maxvalue":10}`, "increment", ErrMissingField},
+		{`{"current":1,"increment":0,"minvalue":1,"maxvalue":10}`, "increment", ErrZeroStep},
+		{`{"current":1,"increment":1,"minvalue":0,"maxvalue":10}`, "minvalue", ErrOutOfBounds},
+		{`{"current":1,"increment":1,"minvalue":1,"maxvalue":18446744073709551615}`, "maxvalue", ErrOutOfBounds},
+		{`{"current":5,"increment":1,"minvalue":10,"maxvalue":5}`, "maxvalue", ErrEmptyRange},
+		{`{"current":11,"increment":1,"minvalue":1,"maxvalue":10}`, "current", ErrOutOfRange},
+		{`{"current":1,"increment":2,"minvalue":4,"maxvalue":10}`, "current", ErrOutOfRange},
+		{`{"current":0,"increment":2,"minvalue":1,"maxvalue":10}`, "increment", ErrStepTooLarge},
+		{`{"current":6,"increment":5,"minvalue":1,"maxvalue":100}`, "increment", ErrStepTooLarge},
+	}
+
+	for _, tt := range tests {
+		for _, seq := range []interface{ Load([]byte) error }{new(Sequence), new(AtomicSequence)} {
+			err := seq.Load([]byte(tt.data))
+
+			var ierr *InvariantError
+			if !errors.As(err, &ierr) || ierr.Field != tt.field || !errors.Is(err, tt.err) {
+				t.Errorf("expected %s error for %s loading %s, got %v", tt.field, tt.err, tt.data, err)
+			}
+		}
+	}
+
+	// A failed load does not modify the sequence.
+	seq := new(Sequence)
+	seq.Load([]byte(`{"current":11,"increment":1,"minvalue":1,"maxvalue":10}`))
+	if seq.State() != Uninitialized || seq.current != 0 {
+		t.Errorf("failed load modified the sequence to %s", seq)
+	}
+}
+
+// Test that every valid state is loaded.
+func TestLoadValid(t *testing.T) {
+	tests := []string{
+		`{"current":0,"increment":1,"minvalue":1,"maxvalue":10}`,
+		`{"current":10,"increment":1,"minvalue":1,"maxvalue":10}`,
+		`{"current":2,"increment":3,"minvalue":3,"maxvalue":10}`,
+		`{"current":15,"increment":10,"minvalue":10,"maxvalue":100}`,
+	}
+
+	for _, data := range tests {
+		if err := new(Sequence).Load([]byte(data)); err != nil {
+			t.Errorf("could not load %s: %s", data, err)
+		}
+	}
+}
+
+// Test that unknown fields are only rejected in strict mode.
+func TestLoadStrict(t *testing.T) {
+	data := []byte(`{"current":1,"increment":1,"minvalue":1,"maxvalue":10,"zeta":1,"alpha":2}`)
+
+	if err := new(Sequence).Load(data); err != nil {
+		t.Errorf("expected unknown fields to be ignored, got %s", err)
+	}
+
+	for _, seq := range []interface{ LoadStrict([]byte) error }{new(Sequence), new(AtomicSequence)} {
+		var ierr *InvariantError
+		if err := seq.LoadStrict(data); !errors.As(err, &ierr) || ierr.Field != "alpha" || !errors.Is(err, ErrUnknownField) {
+			t.Errorf("expected unknown field error for alpha, got %v", err)
+		}
+	}
+
+	big := []byte(`{"current":"1","increment":"1","minvalue":"1","maxvalue":"10","extra":"1"}`)
+	if err := new(BigSequence).LoadStrict(big); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected unknown field error for big sequence, got %v", err)
+	}
+
+	seq, _ := New(10)
+	seq.Next()
+	dump, _ := seq.Dump()
+
+	loaded := new(Sequence)
+	if err := loaded.LoadStrict(dump); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if loaded.String() != seq.String() {
+		t.Errorf("expected %s got %s", seq, loaded)
+	}
+}
+
+// Test the message of an invariant error.
+func TestInvariantError(t *testing.T) {
+	err := &InvariantError{Field: "increment", Err: ErrZeroStep}
+	if msg := err.Error(); msg != "invalid sequence increment: step must not be zero" {
+		t.Errorf("unexpected error message %q", msg)
+	}
+}
+
+//===========================================================================
+// Fuzz Tests
+//===========================================================================
+
+// Fuzz the parser with arbitrary data: a loaded sequence must be usable and
+// must round trip through Dump.
+func FuzzLoad(f *testing.F) {
+	seq, _ := New(2, 100, 2)
+	for i := 0; i < 3; i++ {
+		data, _ := seq.Dump()
+		f.Add(data)
+		seq.Next()
+	}
+
+	f.Add([]byte(`{"current":0,"increment":0,"minvalue":0,"maxvalue":0}`))
+	f.Add([]byte(`{"current":18446744073709551614,"increment":1,"minvalue":1,"maxvalue":18446744073709551614}`))
+	f.Add([]byte(`{"current":1,"increment":1,"minvalue":1,"maxvalue":10,"extra":1}`))
+	f.Add([]byte(`{"current":6,"increment":5,"minvalue":1,"maxvalue":100}`))
+	f.Add([]byte(`[]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		seq := new(Sequence)
+		if err := seq.Load(data); err != nil {
+			if seq.State() != Uninitialized {
+				t.Fatalf("failed load left sequence in state %s", seq.State())
+			}
+			return
+		}
+
+		if err := seq.validate(); err != nil {
+			t.Fatalf("loaded invalid state: %s", err)
+		}
+
+		dump, err := seq.Dump()
+		if err != nil {
+			t.Fatalf("could not dump loaded sequence: %s", err)
+		}
+
+		loaded := new(Sequence)
+		if err := loaded.LoadStrict(dump); err != nil {
+			t.Fatalf("could not load dumped sequence: %s", err)
+		}
+
+		if loaded.String() != seq.String() {
+			t.Fatalf("round trip changed %s into %s", seq, loaded)
+		}
+
+		rem := seq.Remaining()
+		val, err := seq.Next()
+		if (err == nil) != (rem > 0) {
+			t.Fatalf("next returned %d, %v with %d remaining", val, err, rem)
+		}
+
+		if err == nil && (val < seq.minvalue || val > seq.maxvalue) {
+			t.Fatalf("next returned %d outside of the range of %s", val, seq)
+		}
+
+		if err := seq.Restart(); err != nil {
+			t.Fatalf("could not restart loaded sequence: %s", err)
+		}
+	})
+}
+
+// Fuzz the big sequence parser with arbitrary data.
+func FuzzLoadBig(f *testing.F) {
+	f.Add([]byte(`{"current":"0","increment":"1","minvalue":"1","maxvalue":"10"}`))
+	f.Add([]byte(`{"current":"340282366920938463463374607431768211456","increment":"1","minvalue":"1","maxvalue":"340282366920938463463374607431768211457"}`))
+	f.Add([]byte(`{"current":"5","increment":"0","minvalue":"1","maxvalue":"10"}`))
+	f.Add([]byte(`{"current":"0","increment":"5","minvalue":"1","maxvalue":"100"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		seq := new(BigSequence)
+		if err := seq.Load(data); err != nil {
+			return
+		}
+
+		current, increment, minvalue, maxvalue := seq.values()
+		if increment.Sign() <= 0 || minvalue.Sign() <= 0 || minvalue.Cmp(maxvalue) > 0 || current.Cmp(maxvalue) > 0 {
+			t.Fatalf("loaded invalid state %s", seq)
+		}
+
+		if val, err := seq.Next(); err == nil && (val.Cmp(minvalue) < 0 || val.Cmp(maxvalue) > 0) {
+			t.Fatalf("next returned %s outside of the range of %s", val, seq)
+		}
+
+		if err := seq.Restart(); err != nil {
+			t.Fatalf("could not restart loaded sequence: %s", err)
+		}
+	})
+}